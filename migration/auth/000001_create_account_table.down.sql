@@ -1 +0,0 @@
-DROP TABLE IF EXISTS `auth`.`account`;
\ No newline at end of file
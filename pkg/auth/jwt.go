@@ -1,15 +1,22 @@
 package auth
 
 import (
+	"bytes"
 	"context"
+	"crypto"
 	_ "embed"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"pkg/clock"
 )
 
 //go:embed cert/secret.pem
@@ -22,6 +29,11 @@ type JWTConfig struct {
 	Issuer     string
 	ExpiresIn  time.Duration
 	SignMethod jwa.SignatureAlgorithm
+	// PrivateKey and PublicKey are PEM encoded. The embedded development keys are used when both are empty
+	PrivateKey []byte
+	PublicKey  []byte
+	// Clock is used for iat, exp and validation. Defaults to clock.Real
+	Clock clock.Clock
 }
 
 // User represents minimal user information for JWT token
@@ -33,28 +45,106 @@ type User struct {
 
 // JWTManager handles JWT token generation and verification
 type JWTManager struct {
-	privateKey, publicKey jwk.Key
-	config                JWTConfig
+	keys   atomic.Pointer[keySet]
+	config JWTConfig
+}
+
+// keySet signs with the current private key and verifies with the current public key,
+// then the previous one so tokens issued before a rotation stay valid until they expire
+type keySet struct {
+	privatePEM, publicPEM []byte
+	privateKey            jwk.Key
+	publicKeys            []jwk.Key
 }
 
 // NewJWTManager creates a new JWT manager
 func NewJWTManager(config JWTConfig) (*JWTManager, error) {
+	if config.Clock == nil {
+		config.Clock = clock.Real{}
+	}
 	manager := &JWTManager{
 		config: config,
 	}
 
-	var err error
-	manager.privateKey, err = parse(rawPrivateKey)
+	if err := manager.SetKeys(config.PrivateKey, config.PublicKey); err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// SetKeys replaces the signing key pair. The embedded development keys are used when both are empty.
+// The current keys are kept when the new ones are invalid, the public key does not belong to the private key
+// or the pair cannot sign and verify with the configured SignMethod.
+func (j *JWTManager) SetKeys(privatePEM, publicPEM []byte) error {
+	if len(privatePEM) == 0 && len(publicPEM) == 0 {
+		privatePEM, publicPEM = rawPrivateKey, rawPublicKey
+	}
+
+	current := j.keys.Load()
+	if current != nil && bytes.Equal(current.privatePEM, privatePEM) && bytes.Equal(current.publicPEM, publicPEM) {
+		return nil
+	}
+
+	privateKey, err := parse(privatePEM)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %w", err)
+		return fmt.Errorf("failed to parse private key: %w", err)
+	}
+	publicKey, err := parse(publicPEM)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if err := matchKeys(privateKey, publicKey); err != nil {
+		return err
+	}
+	if err := j.checkSign(privateKey, publicKey); err != nil {
+		return err
 	}
 
-	manager.publicKey, err = parse(rawPublicKey)
+	next := &keySet{
+		privatePEM: privatePEM,
+		publicPEM:  publicPEM,
+		privateKey: privateKey,
+		publicKeys: []jwk.Key{publicKey},
+	}
+	if current != nil {
+		next.publicKeys = append(next.publicKeys, current.publicKeys[0])
+	}
+	j.keys.Store(next)
+	return nil
+}
+
+// matchKeys compares the thumbprint of the public key derived from privateKey with publicKey.
+// A mismatched pair would sign tokens that no one can verify.
+func matchKeys(privateKey, publicKey jwk.Key) error {
+	derived, err := jwk.PublicKeyOf(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to derive public key: %w", err)
+	}
+	want, err := derived.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to compute thumbprint: %w", err)
+	}
+	got, err := publicKey.Thumbprint(crypto.SHA256)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse public key: %w", err)
+		return fmt.Errorf("failed to compute thumbprint: %w", err)
 	}
+	if !bytes.Equal(want, got) {
+		return errors.New("public key does not match private key")
+	}
+	return nil
+}
 
-	return manager, nil
+// checkSign signs and verifies a payload with the configured method,
+// so keys of a different type are rejected here instead of failing every token later.
+func (j *JWTManager) checkSign(privateKey, publicKey jwk.Key) error {
+	signed, err := jws.Sign([]byte("check"), jws.WithKey(j.config.SignMethod, privateKey))
+	if err != nil {
+		return fmt.Errorf("cannot sign with %s: %w", j.config.SignMethod, err)
+	}
+	if _, err := jws.Verify(signed, jws.WithKey(j.config.SignMethod, publicKey)); err != nil {
+		return fmt.Errorf("cannot verify with %s: %w", j.config.SignMethod, err)
+	}
+	return nil
 }
 
 func parse(rawKey []byte) (jwk.Key, error) {
@@ -67,7 +157,7 @@ func parse(rawKey []byte) (jwk.Key, error) {
 
 // GenerateToken creates a new JWT token for the given user
 func (j *JWTManager) GenerateToken(_ context.Context, u User) (string, error) {
-	now := time.Now()
+	now := j.config.Clock.Now()
 	tok, err := jwt.NewBuilder().
 		Issuer(j.config.Issuer).
 		IssuedAt(now).
@@ -83,7 +173,7 @@ func (j *JWTManager) GenerateToken(_ context.Context, u User) (string, error) {
 	}
 
 	// JWT 토큰에 서명
-	signed, err := jwt.Sign(tok, jwt.WithKey(j.config.SignMethod, j.privateKey))
+	signed, err := jwt.Sign(tok, jwt.WithKey(j.config.SignMethod, j.keys.Load().privateKey))
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -93,16 +183,23 @@ func (j *JWTManager) GenerateToken(_ context.Context, u User) (string, error) {
 
 // VerifyToken verifies and parses the JWT token
 func (j *JWTManager) VerifyToken(ctx context.Context, tokenString string) (jwt.Token, error) {
-	tok, err := jwt.Parse(
-		[]byte(tokenString),
-		jwt.WithKey(j.config.SignMethod, j.publicKey),
-		jwt.WithValidate(true),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to verify token: %w", err)
+	// return the error of the current key so that causes such as expiry are not hidden
+	var firstErr error
+	for _, key := range j.keys.Load().publicKeys {
+		tok, err := jwt.Parse(
+			[]byte(tokenString),
+			jwt.WithKey(j.config.SignMethod, key),
+			jwt.WithValidate(true),
+			jwt.WithClock(jwt.ClockFunc(j.config.Clock.Now)),
+		)
+		if err == nil {
+			return tok, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
 	}
-
-	return tok, nil
+	return nil, fmt.Errorf("failed to verify token: %w", firstErr)
 }
 
 // GetTokenKey returns Redis key for JWT token
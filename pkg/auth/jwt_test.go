@@ -2,7 +2,13 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+
+	"pkg/clock"
 )
 
 func TestEmbed(t *testing.T) {
@@ -15,3 +21,128 @@ func TestEmbed(t *testing.T) {
 		t.Errorf("expected %s, but got %s", expected, rawPrivateKey)
 	}
 }
+
+func TestGenerateToken_Clock(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	manager, err := NewJWTManager(JWTConfig{
+		Issuer:     "test",
+		ExpiresIn:  time.Hour,
+		SignMethod: jwa.RS256,
+		Clock:      fake,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	signed, err := manager.GenerateToken(context.Background(), User{ID: 1, Email: "test@example.com", Role: "admin"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tok, err := manager.VerifyToken(context.Background(), signed)
+	if err != nil {
+		t.Fatalf("failed to verify token: %v", err)
+	}
+	if !tok.IssuedAt().Equal(now) {
+		t.Errorf("expected iat %v, got %v", now, tok.IssuedAt())
+	}
+	if !tok.Expiration().Equal(now.Add(time.Hour)) {
+		t.Errorf("expected exp %v, got %v", now.Add(time.Hour), tok.Expiration())
+	}
+
+	// 만료 시각이 지나면 같은 시계로 검증할 때 실패한다.
+	fake.Advance(time.Hour + time.Second)
+	if _, err = manager.VerifyToken(context.Background(), signed); err == nil {
+		t.Errorf("expected expired token error")
+	}
+}
+
+func TestNewJWTManager_Keys(t *testing.T) {
+	config := JWTConfig{Issuer: "test", ExpiresIn: time.Hour, SignMethod: jwa.RS256}
+
+	config.PrivateKey, config.PublicKey = rawPrivateKey, rawPublicKey
+	if _, err := NewJWTManager(config); err != nil {
+		t.Errorf("expected configured keys to be accepted: %v", err)
+	}
+
+	config.PrivateKey, config.PublicKey = rawPrivateKey, nil
+	if _, err := NewJWTManager(config); err == nil {
+		t.Error("expected error when only the private key is set")
+	}
+
+	config.PrivateKey, config.PublicKey = []byte("invalid"), rawPublicKey
+	if _, err := NewJWTManager(config); err == nil {
+		t.Error("expected error for invalid private key")
+	}
+}
+
+func TestJWTManager_SetKeys(t *testing.T) {
+	manager, err := NewJWTManager(JWTConfig{Issuer: "test", ExpiresIn: time.Hour, SignMethod: jwa.RS256})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+	before, err := manager.GenerateToken(ctx, User{ID: 1})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if err := manager.SetKeys([]byte("invalid"), rawPublicKey); err == nil {
+		t.Error("expected error for invalid private key")
+	}
+
+	// a public key from another pair is rejected and the current keys are kept
+	privatePEM, publicPEM := generateKeys(t)
+	if err := manager.SetKeys(privatePEM, rawPublicKey); err == nil {
+		t.Error("expected error for mismatched key pair")
+	}
+	if _, err := manager.VerifyToken(ctx, before); err != nil {
+		t.Errorf("expected current keys to be kept: %v", err)
+	}
+
+	// a matching pair that cannot sign with the configured method is rejected too
+	ecPrivatePEM, ecPublicPEM, err := GenerateKeys(jwa.ES256)
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+	if err := manager.SetKeys(ecPrivatePEM, ecPublicPEM); err == nil {
+		t.Error("expected error for keys of another sign method")
+	}
+	if _, err := manager.GenerateToken(ctx, User{ID: 1}); err != nil {
+		t.Errorf("expected current keys to be kept: %v", err)
+	}
+
+	if err := manager.SetKeys(privatePEM, publicPEM); err != nil {
+		t.Fatalf("failed to set keys: %v", err)
+	}
+	after, err := manager.GenerateToken(ctx, User{ID: 1})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	// the previous public key still verifies tokens issued before the rotation
+	for name, token := range map[string]string{"before": before, "after": after} {
+		if _, err := manager.VerifyToken(ctx, token); err != nil {
+			t.Errorf("expected %s token to be verified: %v", name, err)
+		}
+	}
+
+	// only one previous key is kept
+	privatePEM, publicPEM = generateKeys(t)
+	if err := manager.SetKeys(privatePEM, publicPEM); err != nil {
+		t.Fatalf("failed to set keys: %v", err)
+	}
+	if _, err := manager.VerifyToken(ctx, before); err == nil {
+		t.Error("expected token signed with a retired key to be rejected")
+	}
+}
+
+func generateKeys(t *testing.T) (privatePEM, publicPEM []byte) {
+	t.Helper()
+	privatePEM, publicPEM, err := GenerateKeys(jwa.RS256)
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+	return privatePEM, publicPEM
+}
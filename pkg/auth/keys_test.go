@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+)
+
+func TestGenerateKeys(t *testing.T) {
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.RS256, jwa.PS256, jwa.ES256, jwa.ES384, jwa.ES512, jwa.EdDSA} {
+		t.Run(alg.String(), func(t *testing.T) {
+			privatePEM, publicPEM, err := GenerateKeys(alg)
+			if err != nil {
+				t.Fatalf("expected nil, got %v", err)
+			}
+
+			manager, err := NewJWTManager(JWTConfig{
+				Issuer:     "test",
+				ExpiresIn:  time.Hour,
+				SignMethod: alg,
+				PrivateKey: privatePEM,
+				PublicKey:  publicPEM,
+			})
+			if err != nil {
+				t.Fatalf("failed to create manager: %v", err)
+			}
+			token, err := manager.GenerateToken(context.Background(), User{ID: 1, Email: "test@example.com"})
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+			if _, err = manager.VerifyToken(context.Background(), token); err != nil {
+				t.Errorf("failed to verify token: %v", err)
+			}
+		})
+	}
+
+	if _, _, err := GenerateKeys(jwa.HS256); err == nil {
+		t.Error("expected error for symmetric algorithm")
+	}
+}
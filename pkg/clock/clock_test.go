@@ -0,0 +1,24 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(now)
+	if got := f.Now(); !got.Equal(now) {
+		t.Errorf("expected %v, got %v", now, got)
+	}
+
+	f.Advance(time.Hour)
+	if got := f.Now(); !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected %v, got %v", now.Add(time.Hour), got)
+	}
+
+	f.Set(now)
+	if got := f.Now(); !got.Equal(now) {
+		t.Errorf("expected %v, got %v", now, got)
+	}
+}
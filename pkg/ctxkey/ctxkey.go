@@ -0,0 +1,76 @@
+package ctxkey
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"pkg/auth"
+)
+
+// key 다른 패키지의 컨텍스트 키와 충돌하지 않도록 타입을 분리한다.
+type key int
+
+const (
+	requestTimeKey key = iota
+	requestIDKey
+	userKey
+	loggerKey
+	primaryPinKey
+)
+
+// WithRequestTime returns a copy of ctx that carries the time the request was received
+func WithRequestTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, requestTimeKey, t)
+}
+
+// RequestTime returns the request time stored in ctx
+func RequestTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(requestTimeKey).(time.Time)
+	return t, ok && !t.IsZero()
+}
+
+// WithRequestID returns a copy of ctx that carries the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID stored in ctx
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
+
+// WithUser returns a copy of ctx that carries the authenticated user
+func WithUser(ctx context.Context, u auth.User) context.Context {
+	return context.WithValue(ctx, userKey, u)
+}
+
+// User returns the authenticated user stored in ctx
+func User(ctx context.Context) (auth.User, bool) {
+	u, ok := ctx.Value(userKey).(auth.User)
+	return u, ok
+}
+
+// WithLogger returns a copy of ctx that carries l
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// Logger returns the logger stored in ctx
+func Logger(ctx context.Context) (*slog.Logger, bool) {
+	l, ok := ctx.Value(loggerKey).(*slog.Logger)
+	return l, ok && l != nil
+}
+
+// WithPrimaryPin returns a copy of ctx that carries a flag set once the request writes to the primary
+func WithPrimaryPin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryPinKey, new(atomic.Bool))
+}
+
+// PrimaryPin returns the flag stored in ctx
+func PrimaryPin(ctx context.Context) (*atomic.Bool, bool) {
+	p, ok := ctx.Value(primaryPinKey).(*atomic.Bool)
+	return p, ok && p != nil
+}
@@ -0,0 +1,56 @@
+package ctxkey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pkg/auth"
+)
+
+func TestAccessors(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := RequestTime(ctx); ok {
+		t.Errorf("expected no request time")
+	}
+	if _, ok := RequestID(ctx); ok {
+		t.Errorf("expected no request id")
+	}
+	if _, ok := User(ctx); ok {
+		t.Errorf("expected no user")
+	}
+	if _, ok := Logger(ctx); ok {
+		t.Errorf("expected no logger")
+	}
+	if _, ok := PrimaryPin(ctx); ok {
+		t.Errorf("expected no primary pin")
+	}
+
+	now := time.Now()
+	user := auth.User{ID: 1, Email: "test@example.com", Role: "admin"}
+	ctx = WithRequestTime(ctx, now)
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithUser(ctx, user)
+	ctx = WithPrimaryPin(ctx)
+
+	if got, ok := RequestTime(ctx); !ok || !got.Equal(now) {
+		t.Errorf("expected request time %v, got %v", now, got)
+	}
+	if got, ok := RequestID(ctx); !ok || got != "req-1" {
+		t.Errorf("expected request id req-1, got %s", got)
+	}
+	if got, ok := User(ctx); !ok || got != user {
+		t.Errorf("expected user %v, got %v", user, got)
+	}
+	if got, ok := PrimaryPin(ctx); !ok || got.Load() {
+		t.Errorf("expected unset primary pin, got %v", got)
+	}
+}
+
+func TestKeyCollision(t *testing.T) {
+	// 문자열 키로 저장한 값은 읽지 않는다.
+	ctx := context.WithValue(context.Background(), "time", time.Now())
+	if _, ok := RequestTime(ctx); ok {
+		t.Errorf("expected string key to be ignored")
+	}
+}
@@ -0,0 +1,79 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Lifecycle closes resources in reverse order of registration,
+// so resources are released in reverse order of startup.
+type Lifecycle struct {
+	mu      sync.Mutex
+	closers []closer
+}
+
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Add registers fn to be called by Close
+func (l *Lifecycle) Add(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closers = append(l.closers, closer{name: name, close: fn})
+}
+
+// AddCloser registers c to be closed by Close
+func (l *Lifecycle) AddCloser(name string, c io.Closer) {
+	l.Add(name, func(context.Context) error {
+		return c.Close()
+	})
+}
+
+// Close calls the registered closers in reverse order and joins their errors.
+// A closer that does not return before ctx is done is abandoned so the rest can still run.
+func (l *Lifecycle) Close(ctx context.Context) error {
+	l.mu.Lock()
+	closers := l.closers
+	l.closers = nil
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
+		if err := closeWithContext(ctx, c); err != nil {
+			slog.Error("failed to close", "resource", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("close %s: %w", c.name, err))
+			continue
+		}
+		slog.Debug("closed", "resource", c.name)
+	}
+	return errors.Join(errs...)
+}
+
+func closeWithContext(ctx context.Context, c closer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.close(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func TestClose_ReverseOrder(t *testing.T) {
+	var order []string
+	l := New()
+	for _, name := range []string{"mysql", "redis", "http"} {
+		l.Add(name, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if expected := []string{"http", "redis", "mysql"}; !slices.Equal(order, expected) {
+		t.Errorf("expected %v, got %v", expected, order)
+	}
+
+	// 두 번째 Close 는 아무것도 하지 않는다.
+	order = nil
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if len(order) != 0 {
+		t.Errorf("expected no close, got %v", order)
+	}
+}
+
+func TestClose_JoinErrors(t *testing.T) {
+	errRedis := errors.New("redis")
+	errMySQL := errors.New("mysql")
+
+	var closed []string
+	l := New()
+	l.AddCloser("mysql", closerFunc(func() error {
+		closed = append(closed, "mysql")
+		return errMySQL
+	}))
+	l.AddCloser("redis", closerFunc(func() error {
+		closed = append(closed, "redis")
+		return errRedis
+	}))
+
+	err := l.Close(context.Background())
+	if !errors.Is(err, errRedis) || !errors.Is(err, errMySQL) {
+		t.Errorf("expected both errors, got %v", err)
+	}
+	if expected := []string{"redis", "mysql"}; !slices.Equal(closed, expected) {
+		t.Errorf("expected %v, got %v", expected, closed)
+	}
+}
+
+func TestClose_Timeout(t *testing.T) {
+	l := New()
+	l.Add("slow", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := l.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected Close to give up before the closer returns, took %v", elapsed)
+	}
+}
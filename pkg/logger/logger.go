@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"pkg/ctxkey"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 로그에 값을 남기지 않는 속성 키
+var sensitiveKeys = map[string]struct{}{
+	"password":      {},
+	"token":         {},
+	"secret":        {},
+	"authorization": {},
+}
+
+// New creates a logger that writes text in dev and JSON in other environments
+func New(w io.Writer, env string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}
+
+	if env == "dev" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if _, ok := sensitiveKeys[strings.ToLower(a.Key)]; ok {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// WithContext returns a copy of ctx that carries l
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return ctxkey.WithLogger(ctx, l)
+}
+
+// FromContext returns the logger stored in ctx, or slog.Default if there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctxkey.Logger(ctx); ok {
+		return l
+	}
+	return slog.Default()
+}
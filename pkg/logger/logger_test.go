@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew_JSONRedact(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "prod", slog.LevelInfo)
+	l.Info("login", "email", "test@example.com", "password", "password123", "Token", "jwt")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected json output, got %s: %v", buf.String(), err)
+	}
+	if got["email"] != "test@example.com" {
+		t.Errorf("expected email test@example.com, got %v", got["email"])
+	}
+	for _, key := range []string{"password", "Token"} {
+		if got[key] != redacted {
+			t.Errorf("expected %s to be redacted, got %v", key, got[key])
+		}
+	}
+}
+
+func TestNew_DevText(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "dev", slog.LevelInfo)
+	l.Debug("hidden")
+	l.Info("shown", "secret", "abc")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("expected debug log to be filtered, got %s", out)
+	}
+	if !strings.Contains(out, "msg=shown") || !strings.Contains(out, "secret="+redacted) {
+		t.Errorf("unexpected text output: %s", out)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != slog.Default() {
+		t.Errorf("expected default logger without context logger")
+	}
+
+	var buf bytes.Buffer
+	ctx = WithContext(ctx, New(&buf, "prod", slog.LevelInfo).With("user_id", 1))
+	FromContext(ctx).Info("login")
+
+	if !strings.Contains(buf.String(), `"user_id":1`) {
+		t.Errorf("expected user_id in log, got %s", buf.String())
+	}
+}
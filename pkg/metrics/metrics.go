@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// unmatchedRoute 등록되지 않은 경로는 하나의 라벨로 모아 라벨 수가 늘어나지 않게 한다.
+const unmatchedRoute = "unmatched"
+
+// NewRegistry creates a registry with the Go runtime and process collectors
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Handler serves the metrics gathered by reg
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg})
+}
+
+// HTTP holds per-route request metrics
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+	}
+	reg.MustRegister(m.requests, m.duration)
+	return m
+}
+
+// Observe records a finished request. route is the ServeMux pattern that matched the request
+func (m *HTTP) Observe(route, method string, status int, d time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(route, method).Observe(d.Seconds())
+}
+
+// Panics counts handler panics by route
+type Panics struct {
+	panics *prometheus.CounterVec
+}
+
+func NewPanics(reg prometheus.Registerer) *Panics {
+	m := &Panics{
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_panics_total",
+			Help: "Number of recovered handler panics by route.",
+		}, []string{"route"}),
+	}
+	reg.MustRegister(m.panics)
+	return m
+}
+
+func (m *Panics) Inc(route string) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	m.panics.WithLabelValues(route).Inc()
+}
+
+// RegisterDBStats exports the sql.DB connection pool stats
+func RegisterDBStats(reg prometheus.Registerer, db *sql.DB, name string) {
+	reg.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// PoolStatser is implemented by redisclient.RedisClient
+type PoolStatser interface {
+	PoolStats() *redis.PoolStats
+}
+
+// RegisterRedisPoolStats exports the go-redis connection pool stats
+func RegisterRedisPoolStats(reg prometheus.Registerer, p PoolStatser) {
+	reg.MustRegister(&redisPoolCollector{pool: p})
+}
+
+var (
+	redisHitsDesc = prometheus.NewDesc(
+		"redis_pool_hits_total", "Number of times a free connection was found in the pool.", nil, nil)
+	redisMissesDesc = prometheus.NewDesc(
+		"redis_pool_misses_total", "Number of times a free connection was not found in the pool.", nil, nil)
+	redisTimeoutsDesc = prometheus.NewDesc(
+		"redis_pool_timeouts_total", "Number of times a wait timeout occurred.", nil, nil)
+	redisTotalConnsDesc = prometheus.NewDesc(
+		"redis_pool_total_connections", "Number of total connections in the pool.", nil, nil)
+	redisIdleConnsDesc = prometheus.NewDesc(
+		"redis_pool_idle_connections", "Number of idle connections in the pool.", nil, nil)
+	redisStaleConnsDesc = prometheus.NewDesc(
+		"redis_pool_stale_connections_total", "Number of stale connections removed from the pool.", nil, nil)
+)
+
+type redisPoolCollector struct {
+	pool PoolStatser
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHitsDesc
+	ch <- redisMissesDesc
+	ch <- redisTimeoutsDesc
+	ch <- redisTotalConnsDesc
+	ch <- redisIdleConnsDesc
+	ch <- redisStaleConnsDesc
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.PoolStats()
+	if s == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(redisHitsDesc, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMissesDesc, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeoutsDesc, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotalConnsDesc, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdleConnsDesc, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStaleConnsDesc, prometheus.CounterValue, float64(s.StaleConns))
+}
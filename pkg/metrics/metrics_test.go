@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHTTP_Observe(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewHTTP(reg)
+
+	m.Observe("/login", "POST", http.StatusOK, 10*time.Millisecond)
+	m.Observe("/login", "POST", http.StatusOK, 20*time.Millisecond)
+	m.Observe("", "GET", http.StatusNotFound, time.Millisecond)
+
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("/login", "POST", "200")); got != 2 {
+		t.Errorf("expected 2 login requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(unmatchedRoute, "GET", "404")); got != 1 {
+		t.Errorf("expected 1 unmatched request, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.duration); got != 2 {
+		t.Errorf("expected 2 histogram series, got %d", got)
+	}
+}
+
+type fakePool struct{}
+
+func (fakePool) PoolStats() *redis.PoolStats {
+	return &redis.PoolStats{Hits: 3, TotalConns: 2, IdleConns: 1}
+}
+
+func TestRedisPoolStats(t *testing.T) {
+	reg := NewRegistry()
+	RegisterRedisPoolStats(reg, fakePool{})
+
+	res := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := res.Body.String()
+	for _, want := range []string{
+		"redis_pool_hits_total 3",
+		"redis_pool_total_connections 2",
+		"redis_pool_idle_connections 1",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CORSConfig configures CORS. Origins may contain one "*" wildcard,
+// such as "https://*.example.com", and "*" alone allows every origin.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS adds CORS headers for allowed origins and answers preflight requests
+// itself, so it must wrap the ServeMux that would reject OPTIONS with 405.
+// Requests are passed through untouched when no origin is allowed.
+func CORS(cfg CORSConfig) Middleware {
+	return NewCORSPolicy(cfg).Middleware()
+}
+
+// CORSPolicy is a CORS configuration that can be replaced while serving
+type CORSPolicy struct {
+	policy atomic.Pointer[corsPolicy]
+}
+
+type corsPolicy struct {
+	CORSConfig
+	methods       []string
+	headers       []string
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+func NewCORSPolicy(cfg CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Set(cfg)
+	return p
+}
+
+// Set replaces the configuration for requests that start afterwards
+func (p *CORSPolicy) Set(cfg CORSConfig) {
+	methods := upper(cfg.AllowedMethods)
+	p.policy.Store(&corsPolicy{
+		CORSConfig:    cfg,
+		methods:       methods,
+		headers:       lower(cfg.AllowedHeaders),
+		allowMethods:  strings.Join(methods, ", "),
+		allowHeaders:  strings.Join(cfg.AllowedHeaders, ", "),
+		exposeHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+		maxAge:        strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	})
+}
+
+// Middleware applies the configuration current at the start of each request
+func (p *CORSPolicy) Middleware() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			cfg := p.policy.Load()
+			if len(cfg.AllowedOrigins) == 0 {
+				next(w, r)
+				return
+			}
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !allowedOrigin(cfg.AllowedOrigins, origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next(w, r)
+				return
+			}
+
+			// "*" is not allowed with credentials, so the request origin is echoed instead
+			if slices.Contains(cfg.AllowedOrigins, "*") && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if cfg.exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", cfg.exposeHeaders)
+				}
+				next(w, r)
+				return
+			}
+
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			if slices.Contains(cfg.methods, method) && allowedHeaders(cfg.headers, r.Header.Get("Access-Control-Request-Headers")) {
+				h.Set("Access-Control-Allow-Methods", cfg.allowMethods)
+				if cfg.allowHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", cfg.allowHeaders)
+				}
+				if cfg.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", cfg.maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func allowedOrigin(allowed []string, origin string) bool {
+	for _, a := range allowed {
+		if a == "*" || a == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(a, "*"); ok &&
+			len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedHeaders(allowed []string, requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && !slices.Contains(allowed, h) {
+			return false
+		}
+	}
+	return true
+}
+
+func upper(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToUpper(v)
+	}
+	return out
+}
+
+func lower(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"https://game.example.com", "https://*.preview.example.com"},
+		AllowedMethods:   []string{"get", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := Chain(mux.ServeHTTP, CORS(cfg))
+
+	tests := map[string]struct {
+		method        string
+		origin        string
+		requestMethod string
+		requestHeader string
+		status        int
+		allowOrigin   string
+		allowMethods  string
+	}{
+		"simple": {
+			method: "POST", origin: "https://game.example.com",
+			status: http.StatusOK, allowOrigin: "https://game.example.com",
+		},
+		"wildcard": {
+			method: "POST", origin: "https://pr-1.preview.example.com",
+			status: http.StatusOK, allowOrigin: "https://pr-1.preview.example.com",
+		},
+		"wildcard without subdomain": {
+			method: "POST", origin: "https://.preview.example.com",
+			status: http.StatusOK,
+		},
+		"disallowed origin": {
+			method: "POST", origin: "https://evil.example.com",
+			status: http.StatusOK,
+		},
+		"preflight": {
+			method: "OPTIONS", origin: "https://game.example.com", requestMethod: "POST", requestHeader: "content-type, authorization",
+			status: http.StatusNoContent, allowOrigin: "https://game.example.com", allowMethods: "GET, POST",
+		},
+		"preflight disallowed method": {
+			method: "OPTIONS", origin: "https://game.example.com", requestMethod: "DELETE",
+			status: http.StatusNoContent, allowOrigin: "https://game.example.com",
+		},
+		"preflight disallowed header": {
+			method: "OPTIONS", origin: "https://game.example.com", requestMethod: "POST", requestHeader: "X-Custom",
+			status: http.StatusNoContent, allowOrigin: "https://game.example.com",
+		},
+		"preflight disallowed origin": {
+			method: "OPTIONS", origin: "https://evil.example.com", requestMethod: "POST",
+			status: http.StatusNoContent,
+		},
+		// a plain OPTIONS request is left to the mux
+		"options without request method": {
+			method: "OPTIONS", origin: "https://game.example.com",
+			status: http.StatusMethodNotAllowed, allowOrigin: "https://game.example.com",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/login", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeader != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeader)
+			}
+			res := httptest.NewRecorder()
+			h(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, res.Code)
+			}
+			if got := res.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("expected allow origin %q, got %q", tt.allowOrigin, got)
+			}
+			if got := res.Header().Get("Access-Control-Allow-Methods"); got != tt.allowMethods {
+				t.Errorf("expected allow methods %q, got %q", tt.allowMethods, got)
+			}
+			if tt.allowMethods != "" {
+				if got := res.Header().Get("Access-Control-Max-Age"); got != "600" {
+					t.Errorf("expected max age 600, got %q", got)
+				}
+			}
+			if tt.allowOrigin != "" && res.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("expected credentials to be allowed")
+			}
+		})
+	}
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {}, CORS(CORSConfig{AllowedOrigins: []string{"*"}}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://game.example.com")
+	res := httptest.NewRecorder()
+	h(res, req)
+
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("expected *, got %q", got)
+	}
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {}, CORS(CORSConfig{}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://game.example.com")
+	res := httptest.NewRecorder()
+	h(res, req)
+
+	if got := res.Header().Get("Vary"); got != "" {
+		t.Errorf("expected no CORS headers, got Vary %q", got)
+	}
+}
+
+func TestCORSPolicy_Set(t *testing.T) {
+	p := NewCORSPolicy(CORSConfig{})
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {}, p.Middleware())
+
+	allowOrigin := func() string {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://game.example.com")
+		res := httptest.NewRecorder()
+		h(res, req)
+		return res.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowOrigin(); got != "" {
+		t.Errorf("expected no allow origin, got %q", got)
+	}
+
+	p.Set(CORSConfig{AllowedOrigins: []string{"https://game.example.com"}})
+	if got := allowOrigin(); got != "https://game.example.com" {
+		t.Errorf("expected allow origin after Set, got %q", got)
+	}
+}
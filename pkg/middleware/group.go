@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Group registers routes on a ServeMux under a shared path prefix and middleware stack.
+// Patterns use the ServeMux syntax such as "POST /login", so ServeMux answers
+// 405 with an Allow header when only the method does not match.
+type Group struct {
+	mux    *http.ServeMux
+	prefix string
+	// innermost first, so it can be passed to Chain as is
+	middlewares []Middleware
+}
+
+func NewGroup(mux *http.ServeMux, middlewares ...Middleware) *Group {
+	return &Group{
+		mux:         mux,
+		middlewares: middlewares,
+	}
+}
+
+// Group returns a child group. Its middlewares run after the parent's.
+func (g *Group) Group(prefix string, middlewares ...Middleware) *Group {
+	return &Group{
+		mux:         g.mux,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: slices.Concat(middlewares, g.middlewares),
+	}
+}
+
+// HandleFunc registers h for pattern. Middlewares given here run after the group's.
+func (g *Group) HandleFunc(pattern string, h http.HandlerFunc, middlewares ...Middleware) {
+	g.mux.HandleFunc(g.pattern(pattern), Chain(h, slices.Concat(middlewares, g.middlewares)...))
+}
+
+func (g *Group) Handle(pattern string, h http.Handler, middlewares ...Middleware) {
+	g.HandleFunc(pattern, h.ServeHTTP, middlewares...)
+}
+
+func (g *Group) pattern(pattern string) string {
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		return method + " " + g.prefix + strings.TrimLeft(path, " \t")
+	}
+	return g.prefix + pattern
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Chain wraps f with middlewares in order, so the last one runs first
+func Chain(f http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	for _, m := range middlewares {
+		f = m(f)
+	}
+	return f
+}
+
+// Route returns the path of the pattern that matched r, without the method.
+// It is empty until ServeMux has routed the request.
+func Route(r *http.Request) string {
+	if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+		return strings.TrimLeft(path, " \t")
+	}
+	return r.Pattern
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func record(name string, calls *[]string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next(w, r)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}, record("inner", &calls), record("outer", &calls))
+
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if expected := []string{"outer", "inner", "handler"}; !slices.Equal(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var calls []string
+	var route string
+	mux := http.NewServeMux()
+	root := NewGroup(mux, record("root", &calls))
+	v1 := root.Group("/v1/", record("v1", &calls))
+	v1.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+		route = Route(r)
+	}, record("route", &calls))
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("POST", "/v1/login", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, res.Code)
+	}
+	if expected := []string{"root", "v1", "route", "handler"}; !slices.Equal(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+	if route != "/v1/login" {
+		t.Errorf("expected route /v1/login, got %q", route)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("GET", "/v1/login", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d, got %d", http.StatusMethodNotAllowed, res.Code)
+	}
+	if allow := res.Header().Get("Allow"); !strings.Contains(allow, "POST") {
+		t.Errorf("expected Allow to contain POST, got %q", allow)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("POST", "/login", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, res.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"pkg/response"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleClientTTL is how long a client's bucket is kept after its last request
+const idleClientTTL = 3 * time.Minute
+
+// RateLimit allows RPS requests per second per client with bursts of Burst. RPS 0 disables limiting
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
+// RateLimiter limits requests per client IP with a token bucket.
+// It uses RemoteAddr only, since forwarded headers can be set by the client.
+type RateLimiter struct {
+	limit atomic.Pointer[RateLimit]
+
+	mu        sync.Mutex
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		clients: map[string]*rateClient{},
+	}
+	l.SetLimit(limit)
+	return l
+}
+
+// SetLimit changes the limit for new and existing clients
+func (l *RateLimiter) SetLimit(limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit.Store(&limit)
+	now := time.Now()
+	for _, c := range l.clients {
+		c.limiter.SetLimitAt(now, rate.Limit(limit.RPS))
+		c.limiter.SetBurstAt(now, limit.Burst)
+	}
+}
+
+// Middleware answers 429 with Retry-After once a client runs out of tokens
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if l.limit.Load().RPS <= 0 {
+				next(w, r)
+				return
+			}
+
+			limiter := l.client(clientIP(r), time.Now())
+			reservation := limiter.Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+				response.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+func (l *RateLimiter) client(ip string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > idleClientTTL {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleClientTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		limit := l.limit.Load()
+		c = &rateClient{limiter: rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(RateLimit{RPS: 1, Burst: 2})
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {}, l.Middleware())
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = remoteAddr
+		res := httptest.NewRecorder()
+		h(res, req)
+		return res
+	}
+
+	for i := range 2 {
+		if res := request("10.0.0.1:1234"); res.Code != http.StatusOK {
+			t.Fatalf("request %d: expected %d, got %d", i, http.StatusOK, res.Code)
+		}
+	}
+
+	// the same IP shares a bucket regardless of port
+	res := request("10.0.0.1:5678")
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected %d, got %d", http.StatusTooManyRequests, res.Code)
+	}
+	if got := res.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+
+	if res = request("10.0.0.2:1234"); res.Code != http.StatusOK {
+		t.Errorf("expected other clients to be allowed, got %d", res.Code)
+	}
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	l := NewRateLimiter(RateLimit{})
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {}, l.Middleware())
+
+	for range 100 {
+		res := httptest.NewRecorder()
+		h(res, httptest.NewRequest("POST", "/login", nil))
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, res.Code)
+		}
+	}
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	l := NewRateLimiter(RateLimit{RPS: 1, Burst: 1})
+	now := time.Now()
+
+	l.client("10.0.0.1", now)
+	l.client("10.0.0.2", now.Add(idleClientTTL))
+	l.client("10.0.0.3", now.Add(idleClientTTL+time.Second))
+
+	if _, ok := l.clients["10.0.0.1"]; ok {
+		t.Error("expected idle client to be removed")
+	}
+	if len(l.clients) != 2 {
+		t.Errorf("expected 2 clients, got %d", len(l.clients))
+	}
+}
+
+func TestRateLimiter_SetLimit(t *testing.T) {
+	l := NewRateLimiter(RateLimit{RPS: 1, Burst: 1})
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {}, l.Middleware())
+
+	request := func() int {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		res := httptest.NewRecorder()
+		h(res, req)
+		return res.Code
+	}
+
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, code)
+	}
+	if code := request(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected %d, got %d", http.StatusTooManyRequests, code)
+	}
+
+	// existing clients follow the new limit
+	l.SetLimit(RateLimit{RPS: 1000, Burst: 10})
+	time.Sleep(10 * time.Millisecond)
+	if code := request(); code != http.StatusOK {
+		t.Errorf("expected %d after raising the limit, got %d", http.StatusOK, code)
+	}
+
+	l.SetLimit(RateLimit{})
+	for range 20 {
+		if code := request(); code != http.StatusOK {
+			t.Fatalf("expected %d after disabling, got %d", http.StatusOK, code)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrDirty means a previous run failed in the middle of a migration.
+	// Fix the schema by hand and update the version table before running again.
+	ErrDirty = errors.New("database is dirty")
+	// ErrUnknownVersion means the version is neither 0 nor one of the loaded migrations
+	ErrUnknownVersion = errors.New("unknown migration version")
+	// ErrLocked means another migration did not release the lock in time
+	ErrLocked = errors.New("failed to acquire migration lock")
+)
+
+// fileName matches golang-migrate style names: {version}_{title}.{up|down}.sql
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a pair of up and down scripts sharing a version
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads migrations from the root of fsys sorted by version. Other files are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[uint64]*Migration{}
+	for _, e := range entries {
+		match := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version %q", e.Name())
+		}
+		b, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s, %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// Migrator applies migrations to MySQL and records the current version in a table
+// compatible with golang-migrate, so either tool can pick up where the other stopped.
+// Scripts with several statements need a connection opened with multiStatements.
+type Migrator struct {
+	db          *sql.DB
+	migrations  []Migration
+	table       string
+	lockName    string
+	lockTimeout time.Duration
+}
+
+type Option func(*Migrator)
+
+// WithTable sets the version table. The default is schema_migrations.
+func WithTable(name string) Option {
+	return func(m *Migrator) {
+		m.table = name
+	}
+}
+
+// WithLock sets the name of the MySQL user lock and how long to wait for it.
+// Migrators sharing a lock name never run at the same time.
+func WithLock(name string, timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockName = name
+		m.lockTimeout = timeout
+	}
+}
+
+func New(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		db:          db,
+		migrations:  migrations,
+		table:       "schema_migrations",
+		lockName:    "schema_migrations",
+		lockTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Version returns the current version, 0 when nothing has been applied
+func (m *Migrator) Version(ctx context.Context) (version uint64, dirty bool, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err = m.version(ctx, conn)
+		return err
+	})
+	return version, dirty, err
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	var latest uint64
+	if len(m.migrations) > 0 {
+		latest = m.migrations[len(m.migrations)-1].Version
+	}
+	return m.Goto(ctx, latest)
+}
+
+// Down reverts every applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.Goto(ctx, 0)
+}
+
+// Goto applies or reverts migrations one by one until the database is at version.
+// A failed script leaves the database dirty at the version it was moving to.
+func (m *Migrator) Goto(ctx context.Context, version uint64) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w: version %d", ErrDirty, current)
+		}
+		i := m.index(current)
+		if current != 0 && i < 0 {
+			return fmt.Errorf("%w: database is at %d", ErrUnknownVersion, current)
+		}
+
+		for current < version {
+			i++
+			next := m.migrations[i]
+			if err := m.apply(ctx, conn, next, true, next.Version); err != nil {
+				return err
+			}
+			current = next.Version
+		}
+		for current > version {
+			prev := uint64(0)
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, m.migrations[i], false, prev); err != nil {
+				return err
+			}
+			current = prev
+			i--
+		}
+		return nil
+	})
+}
+
+// apply marks the database dirty at target, runs the script and then clears the mark.
+// MySQL commits DDL implicitly, so a failed script cannot be rolled back.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mg Migration, up bool, target uint64) error {
+	script, direction := mg.Up, "up"
+	if !up {
+		script, direction = mg.Down, "down"
+	}
+	if script == "" {
+		return fmt.Errorf("no %s migration for version %d", direction, mg.Version)
+	}
+	if err := m.setVersion(ctx, conn, target, true); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if _, err := conn.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to migrate %s %d_%s: %w", direction, mg.Version, mg.Name, err)
+	}
+	if err := m.setVersion(ctx, conn, target, false); err != nil {
+		return err
+	}
+
+	slog.Info("migrated", "direction", direction, "version", mg.Version, "name", mg.Name, "duration", time.Since(start))
+	return nil
+}
+
+func (m *Migrator) index(version uint64) int {
+	return slices.IndexFunc(m.migrations, func(mg Migration) bool {
+		return mg.Version == version
+	})
+}
+
+func (m *Migrator) version(ctx context.Context, conn *sql.Conn) (uint64, bool, error) {
+	var (
+		version uint64
+		dirty   bool
+	)
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM `"+m.table+"` LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// setVersion replaces the single row of the version table. A clean version 0 is stored as no row.
+func (m *Migrator) setVersion(ctx context.Context, conn *sql.Conn, version uint64, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM `"+m.table+"`"); err != nil {
+		return fmt.Errorf("failed to set migration version: %w", err)
+	}
+	if version != 0 || dirty {
+		if _, err = tx.ExecContext(ctx, "INSERT INTO `"+m.table+"` (version, dirty) VALUES (?, ?)", version, dirty); err != nil {
+			return fmt.Errorf("failed to set migration version: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withLock runs fn on a single connection holding the named lock.
+// GET_LOCK belongs to the session, so the lock is released with the connection if the process dies.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var acquired sql.NullInt64
+	if err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", m.lockName, int(m.lockTimeout.Seconds())).Scan(&acquired); err != nil {
+		return fmt.Errorf("%w: %w", ErrLocked, err)
+	}
+	if acquired.Int64 != 1 {
+		return fmt.Errorf("%w: %s", ErrLocked, m.lockName)
+	}
+	defer func() {
+		// release even if ctx was canceled
+		_, releaseErr := conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", m.lockName)
+		err = errors.Join(err, releaseErr)
+	}()
+
+	if _, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS `"+m.table+"` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		return fmt.Errorf("failed to create migration table: %w", err)
+	}
+	return fn(conn)
+}
@@ -0,0 +1,194 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testFS = fstest.MapFS{
+	"000002_add_column.up.sql":     {Data: []byte("ALTER TABLE account ADD COLUMN device_id VARCHAR(128)")},
+	"000002_add_column.down.sql":   {Data: []byte("ALTER TABLE account DROP COLUMN device_id")},
+	"000001_create_table.up.sql":   {Data: []byte("CREATE TABLE account (id BIGINT)")},
+	"000001_create_table.down.sql": {Data: []byte("DROP TABLE account")},
+	"README.md":                    {Data: []byte("ignored")},
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migrations))
+	}
+	if m := migrations[0]; m.Version != 1 || m.Name != "create_table" || m.Down != "DROP TABLE account" {
+		t.Errorf("unexpected first migration %+v", m)
+	}
+	if m := migrations[1]; m.Version != 2 || m.Name != "add_column" {
+		t.Errorf("unexpected second migration %+v", m)
+	}
+
+	_, err = Load(fstest.MapFS{
+		"000001_a.up.sql": {Data: []byte("SELECT 1")},
+		"000001_b.up.sql": {Data: []byte("SELECT 1")},
+	})
+	if err == nil {
+		t.Error("expected error for duplicate version")
+	}
+}
+
+func newMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	m, err := New(db, testFS)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	return m, mock
+}
+
+func expectLock(mock sqlmock.Sqlmock, version any, dirty bool) {
+	mock.ExpectQuery("SELECT GET_LOCK(?, ?)").
+		WithArgs("schema_migrations", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `schema_migrations` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rows := sqlmock.NewRows([]string{"version", "dirty"})
+	if version != nil {
+		rows.AddRow(version, dirty)
+	}
+	mock.ExpectQuery("SELECT version, dirty FROM `schema_migrations` LIMIT 1").WillReturnRows(rows)
+}
+
+func expectVersion(mock sqlmock.Sqlmock, version uint64, dirty bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `schema_migrations`").WillReturnResult(sqlmock.NewResult(0, 1))
+	if version != 0 || dirty {
+		mock.ExpectExec("INSERT INTO `schema_migrations` (version, dirty) VALUES (?, ?)").
+			WithArgs(version, dirty).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT RELEASE_LOCK(?)").
+		WithArgs("schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestMigrator_Up(t *testing.T) {
+	m, mock := newMigrator(t)
+
+	expectLock(mock, nil, false)
+	for _, step := range []struct {
+		version uint64
+		script  string
+	}{
+		{1, "CREATE TABLE account (id BIGINT)"},
+		{2, "ALTER TABLE account ADD COLUMN device_id VARCHAR(128)"},
+	} {
+		expectVersion(mock, step.version, true)
+		mock.ExpectExec(step.script).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectVersion(mock, step.version, false)
+	}
+	expectUnlock(mock)
+
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	m, mock := newMigrator(t)
+
+	// reverting version 2 moves the database back to version 1, reverting 1 clears the table
+	expectLock(mock, 2, false)
+	expectVersion(mock, 1, true)
+	mock.ExpectExec("ALTER TABLE account DROP COLUMN device_id").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 1, false)
+	expectVersion(mock, 0, true)
+	mock.ExpectExec("DROP TABLE account").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 0, false)
+	expectUnlock(mock)
+
+	if err := m.Down(context.Background()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrator_Failed(t *testing.T) {
+	m, mock := newMigrator(t)
+
+	// the failed script leaves version 1 dirty
+	expectLock(mock, nil, false)
+	expectVersion(mock, 1, true)
+	mock.ExpectExec("CREATE TABLE account (id BIGINT)").WillReturnError(errors.New("syntax error"))
+	expectUnlock(mock)
+
+	if err := m.Goto(context.Background(), 1); err == nil {
+		t.Fatal("expected error for failed script")
+	}
+
+	// and the next run refuses to continue
+	expectLock(mock, 1, true)
+	expectUnlock(mock)
+	if err := m.Up(context.Background()); !errors.Is(err, ErrDirty) {
+		t.Errorf("expected ErrDirty, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrator_Goto(t *testing.T) {
+	m, mock := newMigrator(t)
+
+	if err := m.Goto(context.Background(), 3); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("expected ErrUnknownVersion, got %v", err)
+	}
+
+	expectLock(mock, 1, false)
+	expectVersion(mock, 2, true)
+	mock.ExpectExec("ALTER TABLE account ADD COLUMN device_id VARCHAR(128)").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 2, false)
+	expectUnlock(mock)
+
+	if err := m.Goto(context.Background(), 2); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrator_Locked(t *testing.T) {
+	m, mock := newMigrator(t)
+
+	mock.ExpectQuery("SELECT GET_LOCK(?, ?)").
+		WithArgs("schema_migrations", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+
+	if err := m.Up(context.Background()); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,44 +3,252 @@ package mysqlconn
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
+var ErrNotConnected = errors.New("mysql is not connected")
+
+// MySQLConn holds the primary and optional read replicas
 type MySQLConn struct {
-	conn *sqlx.DB
+	conn     *sqlx.DB
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type options struct {
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	tls          string
+	charset      string
+	collation    string
+	location     *time.Location
+	multiStmts   bool
+
+	pingTimeout    time.Duration
+	connectTimeout time.Duration
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	replicas []string
+}
+
+func defaultOptions() options {
+	return options{
+		maxOpenConns:    10,
+		maxIdleConns:    10,
+		connMaxLifetime: 3 * time.Minute,
+		dialTimeout:     3 * time.Second,
+		charset:         "utf8mb4",
+		location:        time.UTC,
+		pingTimeout:     3 * time.Second,
+		connectTimeout:  30 * time.Second,
+		initialBackoff:  500 * time.Millisecond,
+		maxBackoff:      5 * time.Second,
+	}
+}
+
+type Option func(*options)
+
+// WithPool sets the maximum number of open and idle connections
+func WithPool(maxOpen, maxIdle int) Option {
+	return func(o *options) {
+		o.maxOpenConns = maxOpen
+		o.maxIdleConns = maxIdle
+	}
+}
+
+// WithConnLifetime sets how long a connection may be reused and may stay idle. Zero means no limit.
+func WithConnLifetime(maxLifetime, maxIdleTime time.Duration) Option {
+	return func(o *options) {
+		o.connMaxLifetime = maxLifetime
+		o.connMaxIdleTime = maxIdleTime
+	}
+}
+
+// WithTimeouts sets the dial, read and write timeouts of each connection. Zero means no timeout.
+func WithTimeouts(dial, read, write time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = dial
+		o.readTimeout = read
+		o.writeTimeout = write
+	}
+}
+
+// WithTLS sets the tls parameter: "true", "skip-verify", "preferred"
+// or a name registered with mysql.RegisterTLSConfig
+func WithTLS(name string) Option {
+	return func(o *options) {
+		o.tls = name
+	}
+}
+
+// WithCharset sets the connection charset and collation. An empty collation uses the server default.
+func WithCharset(charset, collation string) Option {
+	return func(o *options) {
+		o.charset = charset
+		o.collation = collation
+	}
+}
+
+// WithLocation sets the location used to parse and format time values
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithMultiStatements allows several statements in one query, e.g. to run migration files.
+// It also widens the impact of SQL injection, so keep it off for connections that serve requests.
+func WithMultiStatements() Option {
+	return func(o *options) {
+		o.multiStmts = true
+	}
+}
+
+// WithConnectRetry sets how long New keeps retrying the first ping and the backoff between attempts
+func WithConnectRetry(timeout, initialBackoff, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.connectTimeout = timeout
+		o.initialBackoff = initialBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithReplicas adds read replicas given as host:port. They share the credentials and options of the primary.
+func WithReplicas(addrs ...string) Option {
+	return func(o *options) {
+		o.replicas = addrs
+	}
 }
 
-func New(user, password, host, port, name string) (*MySQLConn, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, password, host, port, name)
-	db, err := sql.Open("mysql", dsn)
+func New(user, password, host, port, name string, opts ...Option) (*MySQLConn, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	db, err := open(user, password, host, port, name, o)
 	if err != nil {
 		return nil, err
 	}
-	db.SetConnMaxLifetime(time.Minute * 3)
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(10)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-	if err = db.PingContext(ctx); err != nil {
+	if err = ping(db, o); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
+	mc := &MySQLConn{conn: sqlx.NewDb(db, "mysql")}
+
+	// 복제본은 늦게 떠도 primary 로 읽을 수 있으므로 시작을 막지 않고 Run 이 다시 확인한다.
+	for _, addr := range o.replicas {
+		r, err := newReplica(user, password, addr, name, o)
+		if err != nil {
+			_ = mc.Close()
+			return nil, err
+		}
+		mc.replicas = append(mc.replicas, r)
+	}
+
+	return mc, nil
+}
 
-	xdb := sqlx.NewDb(db, "mysql")
-	return &MySQLConn{conn: xdb}, nil
+func open(user, password, host, port, name string, o options) (*sql.DB, error) {
+	db, err := sql.Open("mysql", formatDSN(user, password, host, port, name, o))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(o.maxOpenConns)
+	db.SetMaxIdleConns(o.maxIdleConns)
+	db.SetConnMaxLifetime(o.connMaxLifetime)
+	db.SetConnMaxIdleTime(o.connMaxIdleTime)
+	return db, nil
 }
 
 func (mc *MySQLConn) Close() error {
+	var errs []error
+	if mc.conn != nil {
+		errs = append(errs, mc.conn.Close())
+	}
+	for _, r := range mc.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Ping checks that the primary is reachable. Replicas are checked by Run.
+func (mc *MySQLConn) Ping(ctx context.Context) error {
 	if mc.conn == nil {
-		return nil
+		return ErrNotConnected
 	}
-	return mc.conn.Close()
+	return mc.conn.PingContext(ctx)
 }
 
 func (mc *MySQLConn) Conn() *sqlx.DB {
 	return mc.conn
 }
+
+// FormatDSN builds the DSN that New connects with. The password is escaped by the driver.
+func FormatDSN(user, password, host, port, name string, opts ...Option) string {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return formatDSN(user, password, host, port, name, o)
+}
+
+func formatDSN(user, password, host, port, name string, o options) string {
+	cfg := mysql.NewConfig()
+	cfg.User = user
+	cfg.Passwd = password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, port)
+	cfg.DBName = name
+	cfg.ParseTime = true
+	cfg.Loc = o.location
+	cfg.Timeout = o.dialTimeout
+	cfg.ReadTimeout = o.readTimeout
+	cfg.WriteTimeout = o.writeTimeout
+	cfg.TLSConfig = o.tls
+	cfg.Collation = o.collation
+	cfg.MultiStatements = o.multiStmts
+	if o.charset != "" {
+		cfg.Params = map[string]string{"charset": o.charset}
+	}
+	return cfg.FormatDSN()
+}
+
+// ping retries with exponential backoff so that startup survives a database that is still coming up
+func ping(db *sql.DB, o options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.connectTimeout)
+	defer cancel()
+
+	backoff := o.initialBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, pingCancel := context.WithTimeout(ctx, o.pingTimeout)
+		err := db.PingContext(pingCtx)
+		pingCancel()
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("mysql is not ready, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to ping mysql after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, o.maxBackoff)
+	}
+}
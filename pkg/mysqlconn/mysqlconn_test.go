@@ -0,0 +1,65 @@
+package mysqlconn
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestFormatDSN(t *testing.T) {
+	password := "p@ss:w/rd?#"
+	dsn := FormatDSN("auth", password, "db.local", "3306", "auth",
+		WithTimeouts(time.Second, 2*time.Second, 3*time.Second),
+		WithTLS("skip-verify"),
+		WithCharset("utf8mb4", "utf8mb4_unicode_ci"),
+		WithLocation(time.UTC),
+		WithMultiStatements(),
+	)
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", dsn, err)
+	}
+	if cfg.Passwd != password {
+		t.Errorf("expected password %q, but got %q", password, cfg.Passwd)
+	}
+	if cfg.Addr != "db.local:3306" || cfg.DBName != "auth" {
+		t.Errorf("unexpected address %q and database %q", cfg.Addr, cfg.DBName)
+	}
+	if !cfg.ParseTime || cfg.Loc != time.UTC {
+		t.Errorf("expected parseTime in UTC, but got %v in %v", cfg.ParseTime, cfg.Loc)
+	}
+	if cfg.Timeout != time.Second || cfg.ReadTimeout != 2*time.Second || cfg.WriteTimeout != 3*time.Second {
+		t.Errorf("unexpected timeouts %s, %s, %s", cfg.Timeout, cfg.ReadTimeout, cfg.WriteTimeout)
+	}
+	if cfg.TLSConfig != "skip-verify" {
+		t.Errorf("expected tls skip-verify, but got %q", cfg.TLSConfig)
+	}
+	if cfg.Params["charset"] != "utf8mb4" || cfg.Collation != "utf8mb4_unicode_ci" {
+		t.Errorf("unexpected charset %q and collation %q", cfg.Params["charset"], cfg.Collation)
+	}
+	if !cfg.MultiStatements {
+		t.Error("expected multiStatements")
+	}
+}
+
+func TestNew_Retry(t *testing.T) {
+	// a closed port refuses every attempt
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	_ = l.Close()
+
+	start := time.Now()
+	_, err = New("auth", "", host, port, "auth", WithConnectRetry(300*time.Millisecond, 50*time.Millisecond, 100*time.Millisecond))
+	if err == nil {
+		t.Fatal("expected error for unreachable database")
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("expected retries until the timeout, but gave up after %s", elapsed)
+	}
+}
@@ -0,0 +1,137 @@
+package mysqlconn
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"pkg/ctxkey"
+)
+
+// Queryer runs read queries. *sqlx.DB and *sqlx.Tx implement it.
+type Queryer interface {
+	GetContext(ctx context.Context, dst interface{}, query string, args ...any) error
+	SelectContext(ctx context.Context, dst interface{}, query string, args ...any) error
+}
+
+// Execer runs write queries. *sqlx.DB and *sqlx.Tx implement it.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type replica struct {
+	addr        string
+	db          *sqlx.DB
+	pingTimeout time.Duration
+	healthy     atomic.Bool
+}
+
+func newReplica(user, password, addr, name string, o options) (*replica, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replica address %q: %w", addr, err)
+	}
+	db, err := open(user, password, host, port, name, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// start healthy so that a failed first check is logged
+	r := &replica{addr: addr, db: sqlx.NewDb(db, "mysql"), pingTimeout: o.pingTimeout}
+	r.healthy.Store(true)
+	r.check(context.Background())
+	return r, nil
+}
+
+// check updates the health of the replica and logs when it changes
+func (r *replica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.pingTimeout)
+	defer cancel()
+	err := r.db.PingContext(ctx)
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		slog.Info("mysql replica is healthy", "replica", r.addr)
+	} else {
+		slog.Warn("mysql replica is unhealthy", "replica", r.addr, "error", err)
+	}
+}
+
+// WithReadYourWrites returns a copy of ctx whose reads go to the primary once it has written through Writer.
+// It is meant to wrap a single request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return ctxkey.WithPrimaryPin(ctx)
+}
+
+// Pin sends the remaining reads of a read-your-writes context to the primary.
+// Writes through Writer pin automatically, so it is only needed for transactions.
+func Pin(ctx context.Context) {
+	if p, ok := ctxkey.PrimaryPin(ctx); ok {
+		p.Store(true)
+	}
+}
+
+// Reader returns a healthy replica in turn, or the primary when there is none or ctx is pinned
+func (mc *MySQLConn) Reader(ctx context.Context) Queryer {
+	if p, ok := ctxkey.PrimaryPin(ctx); ok && p.Load() {
+		return mc.conn
+	}
+
+	n := uint64(len(mc.replicas))
+	start := mc.next.Add(1)
+	for i := range n {
+		if r := mc.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+	return mc.conn
+}
+
+// Writer returns the primary. A successful write pins a read-your-writes ctx to the primary.
+func (mc *MySQLConn) Writer(ctx context.Context) Execer {
+	if _, ok := ctxkey.PrimaryPin(ctx); ok {
+		return pinningExecer{db: mc.conn}
+	}
+	return mc.conn
+}
+
+type pinningExecer struct {
+	db *sqlx.DB
+}
+
+func (e pinningExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := e.db.ExecContext(ctx, query, args...)
+	if err == nil {
+		Pin(ctx)
+	}
+	return result, err
+}
+
+// Run checks the replicas every interval until ctx is done. Unhealthy replicas are skipped by Reader.
+func (mc *MySQLConn) Run(ctx context.Context, interval time.Duration) error {
+	if len(mc.replicas) == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, r := range mc.replicas {
+				r.check(ctx)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package mysqlconn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func newMock(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return sqlx.NewDb(db, "mysql"), mock
+}
+
+func newTestReplica(t *testing.T) (*replica, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := newMock(t)
+	r := &replica{addr: "replica:3306", db: db, pingTimeout: time.Second}
+	r.healthy.Store(true)
+	return r, mock
+}
+
+// newTestConn returns a connection with healthy replicas and the mock of its primary
+func newTestConn(t *testing.T, replicas int) (*MySQLConn, sqlmock.Sqlmock) {
+	t.Helper()
+	primary, mock := newMock(t)
+	mc := &MySQLConn{conn: primary}
+	for range replicas {
+		r, _ := newTestReplica(t)
+		mc.replicas = append(mc.replicas, r)
+	}
+	return mc, mock
+}
+
+func TestReader(t *testing.T) {
+	ctx := context.Background()
+
+	mc, _ := newTestConn(t, 0)
+	if mc.Reader(ctx) != Queryer(mc.conn) {
+		t.Error("expected primary without replicas")
+	}
+
+	mc, _ = newTestConn(t, 2)
+	first, second := mc.Reader(ctx), mc.Reader(ctx)
+	if first == Queryer(mc.conn) || second == Queryer(mc.conn) || first == second {
+		t.Error("expected replicas in turn")
+	}
+
+	mc.replicas[0].healthy.Store(false)
+	for range 3 {
+		if mc.Reader(ctx) != Queryer(mc.replicas[1].db) {
+			t.Error("expected unhealthy replica to be skipped")
+		}
+	}
+
+	mc.replicas[1].healthy.Store(false)
+	if mc.Reader(ctx) != Queryer(mc.conn) {
+		t.Error("expected primary when no replica is healthy")
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	mc, mock := newTestConn(t, 1)
+	mock.ExpectExec("UPDATE account").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithReadYourWrites(context.Background())
+	if mc.Reader(ctx) == Queryer(mc.conn) {
+		t.Error("expected replica before writing")
+	}
+	if _, err := mc.Writer(ctx).ExecContext(ctx, "UPDATE account SET last_login = NOW()"); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if mc.Reader(ctx) != Queryer(mc.conn) {
+		t.Error("expected primary after writing")
+	}
+
+	// other requests keep reading from replicas
+	if mc.Reader(context.Background()) == Queryer(mc.conn) {
+		t.Error("expected replica for another request")
+	}
+}
+
+func TestReplica_Check(t *testing.T) {
+	r, mock := newTestReplica(t)
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	r.check(context.Background())
+	if r.healthy.Load() {
+		t.Error("expected replica to be unhealthy after a failed ping")
+	}
+
+	mock.ExpectPing()
+	r.check(context.Background())
+	if !r.healthy.Load() {
+		t.Error("expected replica to recover after a successful ping")
+	}
+}
@@ -0,0 +1,86 @@
+package mysqlconn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// ER_LOCK_WAIT_TIMEOUT
+	errLockWaitTimeout = 1205
+	// ER_LOCK_DEADLOCK
+	errLockDeadlock = 1213
+
+	txMaxAttempts    = 3
+	txInitialBackoff = 20 * time.Millisecond
+)
+
+// Tx runs queries inside a transaction
+type Tx interface {
+	Queryer
+	Execer
+}
+
+// WithTx runs fn in a transaction on the primary. See WithTx.
+func (mc *MySQLConn) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx Tx) error) error {
+	return WithTx(ctx, mc.conn, opts, fn)
+}
+
+// WithTx runs fn in a transaction and commits it when fn returns nil, otherwise rolls it back.
+// The whole transaction is retried with backoff on deadlocks and lock wait timeouts,
+// so fn must not have side effects that cannot be repeated.
+// A read-your-writes ctx is pinned to the primary after a successful commit.
+func WithTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(tx Tx) error) error {
+	backoff := txInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, opts, fn)
+		if err == nil {
+			Pin(ctx)
+			return nil
+		}
+		if attempt == txMaxAttempts || !Retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func runTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(tx Tx) error) error {
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Retryable reports whether MySQL rolled back the statement or transaction because of lock contention.
+// fn passed to WithTx must return such errors, because the transaction cannot continue after them.
+func Retryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == errLockDeadlock || mysqlErr.Number == errLockWaitTimeout
+}
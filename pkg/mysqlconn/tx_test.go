@@ -0,0 +1,75 @@
+package mysqlconn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestWithTx(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: errLockDeadlock, Message: "Deadlock found"}
+	lockWait := &mysql.MySQLError{Number: errLockWaitTimeout, Message: "Lock wait timeout exceeded"}
+	other := errors.New("boom")
+
+	tests := map[string]struct {
+		// error returned by the UPDATE on each attempt
+		errs     []error
+		expected error
+	}{
+		"commit":              {errs: []error{nil}},
+		"rollback":            {errs: []error{other}, expected: other},
+		"retry on deadlock":   {errs: []error{deadlock, nil}},
+		"retry on lock wait":  {errs: []error{lockWait, deadlock, nil}},
+		"give up after limit": {errs: []error{deadlock, deadlock, deadlock}, expected: deadlock},
+		"no retry on other":   {errs: []error{other}, expected: other},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, mock := newMock(t)
+			for _, err := range tt.errs {
+				mock.ExpectBegin()
+				exec := mock.ExpectExec("UPDATE account")
+				if err != nil {
+					exec.WillReturnError(err)
+					mock.ExpectRollback()
+				} else {
+					exec.WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				}
+			}
+
+			attempts := 0
+			err := WithTx(context.Background(), db, nil, func(tx Tx) error {
+				attempts++
+				_, err := tx.ExecContext(context.Background(), "UPDATE account SET last_login = NOW()")
+				return err
+			})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, but got %v", tt.expected, err)
+			}
+			if attempts != len(tt.errs) {
+				t.Errorf("expected %d attempts, but got %d", len(tt.errs), attempts)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestWithTx_Pin(t *testing.T) {
+	mc, mock := newTestConn(t, 1)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	ctx := WithReadYourWrites(context.Background())
+	if err := mc.WithTx(ctx, nil, func(tx Tx) error { return nil }); err != nil {
+		t.Fatalf("failed to run transaction: %v", err)
+	}
+	if mc.Reader(ctx) != Queryer(mc.conn) {
+		t.Error("expected primary after commit")
+	}
+}
@@ -2,11 +2,14 @@ package redisclient
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+var ErrNotConnected = errors.New("redis is not connected")
+
 type RedisClient struct {
 	client *redis.Client
 }
@@ -17,6 +20,7 @@ func New(host, port, password string, db int) *RedisClient {
 		Password: password,
 		DB:       db,
 	})
+	client.AddHook(tracingHook{})
 
 	return &RedisClient{
 		client: client,
@@ -27,6 +31,11 @@ func (r *RedisClient) Save(ctx context.Context, key, value string, expire time.D
 	return r.client.Set(ctx, key, value, expire).Err()
 }
 
+// SaveUntil stores value until expireAt so that the key expires with the token it holds
+func (r *RedisClient) SaveUntil(ctx context.Context, key, value string, expireAt time.Time) error {
+	return r.client.SetArgs(ctx, key, value, redis.SetArgs{ExpireAt: expireAt}).Err()
+}
+
 func (r *RedisClient) Load(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
 }
@@ -35,6 +44,29 @@ func (r *RedisClient) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// Ping checks that the server is reachable
+func (r *RedisClient) Ping(ctx context.Context) error {
+	if r.client == nil {
+		return ErrNotConnected
+	}
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisClient) Close() error {
+	if r.client == nil {
+		return nil
+	}
+	return r.client.Close()
+}
+
+// PoolStats returns the connection pool stats for metrics
+func (r *RedisClient) PoolStats() *redis.PoolStats {
+	if r.client == nil {
+		return nil
+	}
+	return r.client.PoolStats()
+}
+
 func GetSessionKey(email string) string {
 	return "session:" + email
 }
@@ -2,6 +2,7 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -15,6 +16,16 @@ func Error(w http.ResponseWriter, message string, status int) {
 	_ = json.NewEncoder(w).Encode(ErrorResponse{Message: message})
 }
 
+// DecodeError writes 413 when the request body exceeded its limit, otherwise 400
+func DecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	Error(w, "invalid request body", http.StatusBadRequest)
+}
+
 func SetContentJSON(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 }
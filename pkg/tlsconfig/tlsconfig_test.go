@@ -0,0 +1,105 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"pkg/tlsconfig/tlstest"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.Server(t)
+	certFile, keyFile := tlstest.WriteFiles(t, dir, certPEM, keyPEM)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %v", err)
+	}
+	first, _ := r.GetCertificate(nil)
+
+	reloaded, err := r.Reload()
+	if err != nil || reloaded {
+		t.Errorf("expected no reload for unchanged files, got %v, %v", reloaded, err)
+	}
+
+	certPEM, keyPEM = ca.Server(t)
+	tlstest.WriteFiles(t, dir, certPEM, keyPEM)
+	reloaded, err = r.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("expected reload, got %v, %v", reloaded, err)
+	}
+	second, _ := r.GetCertificate(nil)
+	if bytes.Equal(first.Certificate[0], second.Certificate[0]) {
+		t.Error("expected a new certificate after reload")
+	}
+
+	// 잘못된 파일로 바뀌면 기존 인증서를 유지한다.
+	if err = os.WriteFile(certFile, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.Reload(); err == nil {
+		t.Error("expected error for invalid certificate")
+	}
+	current, _ := r.GetCertificate(nil)
+	if current != second {
+		t.Error("expected the previous certificate to be kept")
+	}
+}
+
+func TestServer_ClientCert(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	certPEM, keyPEM := ca.Server(t)
+	certFile, keyFile := tlstest.WriteFiles(t, t.TempDir(), certPEM, keyPEM)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %v", err)
+	}
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", Server(r, ca.Pool()))
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) > 0 {
+			_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+		}
+	})}
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	defer srv.Close()
+	url := "https://" + l.Addr().String()
+
+	tests := map[string]struct {
+		certs    []tls.Certificate
+		expected string
+	}{
+		"without client certificate": {},
+		"with client certificate":    {certs: []tls.Certificate{ca.Client(t, "internal")}, expected: "internal"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      ca.Pool(),
+				Certificates: tt.certs,
+			}}}
+			res, err := client.Get(url)
+			if err != nil {
+				t.Fatalf("failed to request: %v", err)
+			}
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			if string(body) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
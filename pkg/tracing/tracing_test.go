@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_Stdout(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(ExporterStdout, "test_service", &buf)
+	if err != nil {
+		t.Fatalf("failed to setup tracing: %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	if err = shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shutdown: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"Name":"test-span"`) || !strings.Contains(out, "test_service") {
+		t.Errorf("expected exported span, got %s", out)
+	}
+}
+
+func TestSetup_Unknown(t *testing.T) {
+	if _, err := Setup("jaeger", "test_service", nil); err == nil {
+		t.Errorf("expected error for unknown exporter")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"auth_service/config"
+	"auth_service/internal/outbox"
+	"auth_service/internal/repository"
+	"auth_service/internal/service"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"pkg/clock"
+	"pkg/logger"
+	"pkg/mysqlconn"
+	"pkg/redisclient"
+)
+
+const usage = `usage: auth_service <command> [arguments]
+
+commands:
+  serve                                       start the HTTP server (default)
+  migrate up | down | goto <version> | version
+  user create -email <email> [-password <password>] [-role admin|user]
+  user list [-after <id>] [-limit <n>]
+  user set-role -id <id> -role admin|user
+  user disable -id <id>
+  token issue -id <id>
+  token verify <token>
+  token revoke -id <id>
+  keys generate [-alg <alg>] [-out <dir>]
+  keys rotate [-private <file>] [-public <file>]`
+
+var errUsage = errors.New(usage)
+
+// execute 첫 인자로 명령을 고른다. 인자가 없으면 서버를 시작한다.
+// 명령의 결과는 w 에, 로그는 표준 에러에 쓴다.
+func execute(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return run(ctx)
+	}
+
+	switch args[0] {
+	case "serve":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return run(ctx)
+	case "migrate":
+		return runMigrate(ctx, args[1:], w)
+	case "user":
+		return runUser(ctx, args[1:], w)
+	case "token":
+		return runToken(ctx, args[1:], w)
+	case "keys":
+		return runKeys(args[1:], w)
+	case "help", "-h", "--help":
+		_, err := fmt.Fprintln(w, usage)
+		return err
+	default:
+		return fmt.Errorf("unknown command %q\n%w", args[0], errUsage)
+	}
+}
+
+// parseFlags 플래그 오류는 사용법과 함께 반환하고 남은 위치 인자를 돌려준다.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%s: %w\n%w", fs.Name(), err, errUsage)
+	}
+	return fs.Args(), nil
+}
+
+// loadConfig 서버와 같은 설정을 읽고 로그는 명령 결과와 섞이지 않도록 표준 에러로 보낸다.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	level, err := parseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger.New(os.Stderr, cfg.Env, level))
+	return cfg, nil
+}
+
+// app 운영 명령이 공유하는 의존성. 서버와 같은 설정으로 만든다.
+type app struct {
+	service *service.AuthService
+	relay   *outbox.Relay
+}
+
+// withApp 연결을 만들어 fn 을 실행하고 닫는다.
+func withApp(ctx context.Context, fn func(a *app) error) (err error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	rl, err := NewReloadable(cfg, clock.Real{})
+	if err != nil {
+		return err
+	}
+	hasher, err := newHasher(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize password hasher: %w", err)
+	}
+
+	// 운영 명령은 복제 지연 없이 primary 만 사용한다.
+	mc, err := newMySQLConn(cfg, mysqlconn.WithReplicas())
+	if err != nil {
+		return fmt.Errorf("failed to connect mysql: %w", err)
+	}
+	rc := redisclient.New(cfg.RedisHost, cfg.RedisPort, cfg.RedisPw, 0)
+	defer func() {
+		err = errors.Join(err, mc.Close(), rc.Close())
+	}()
+
+	outboxRepo := repository.NewOutboxRepository()
+	a := &app{
+		service: service.NewAuthService(mc.Conn(), repository.NewUserRepository(), outboxRepo, rl.jwt, hasher,
+			service.WithClock(rl.clock),
+			service.WithSessionExpire(cfg.SessionExpire),
+		),
+		relay: outbox.NewRelay(mc.Conn(), outboxRepo, rc, outbox.NewLogPublisher(), cfg.OutboxPollInterval, cfg.OutboxBatchSize),
+	}
+	if err = fn(a); err != nil {
+		return err
+	}
+	return a.flush(ctx)
+}
+
+// flush 서버의 relay 를 기다리지 않고 기록한 세션 변경과 이벤트를 바로 적용한다.
+func (a *app) flush(ctx context.Context) error {
+	for {
+		n, err := a.relay.Process(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to apply outbox: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
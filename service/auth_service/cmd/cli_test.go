@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+
+	"pkg/auth"
+)
+
+func TestExecute_Usage(t *testing.T) {
+	tests := map[string][]string{
+		"unknown command":    {"login"},
+		"serve arguments":    {"serve", "now"},
+		"user command":       {"user"},
+		"unknown user":       {"user", "delete"},
+		"missing email":      {"user", "create"},
+		"unknown flag":       {"user", "list", "-offset", "10"},
+		"missing id":         {"token", "issue"},
+		"missing token":      {"token", "verify"},
+		"unexpected args":    {"token", "revoke", "-id", "1", "2"},
+		"keys command":       {"keys"},
+		"rotate without key": {"keys", "rotate"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("JWT_PRIVATE_KEY_FILE", "")
+			t.Setenv("JWT_PUBLIC_KEY_FILE", "")
+			err := execute(context.Background(), args, io.Discard)
+			assert.ErrorContains(t, err, usage)
+		})
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, execute(context.Background(), []string{"help"}, &out))
+	assert.Contains(t, out.String(), "user set-role")
+}
+
+func TestKeys_Generate(t *testing.T) {
+	dir := t.TempDir()
+
+	var out bytes.Buffer
+	assert.NoError(t, execute(context.Background(), []string{"keys", "generate", "-alg", "ES256", "-out", dir}, &out))
+	assert.Contains(t, out.String(), "private.pem")
+
+	privatePEM, err := os.ReadFile(filepath.Join(dir, "current", "private.pem"))
+	assert.NoError(t, err)
+	publicPEM, err := os.ReadFile(filepath.Join(dir, "current", "public.pem"))
+	assert.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, "current", "private.pem"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = auth.NewJWTManager(auth.JWTConfig{SignMethod: jwa.ES256, PrivateKey: privatePEM, PublicKey: publicPEM})
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, execute(context.Background(), []string{"keys", "generate", "-alg", "RS1"}, io.Discard), "invalid JWT sign method")
+}
+
+func TestKeys_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, execute(context.Background(), []string{"keys", "generate", "-out", dir}, io.Discard))
+	privatePath, publicPath := filepath.Join(dir, "current", "private.pem"), filepath.Join(dir, "current", "public.pem")
+	privatePEM, err := os.ReadFile(privatePath)
+	assert.NoError(t, err)
+
+	setRequiredEnv(t)
+	t.Setenv("JWT_PRIVATE_KEY_FILE", privatePath)
+	t.Setenv("JWT_PUBLIC_KEY_FILE", publicPath)
+	cfg := newTestConfig(t)
+	rl := newTestReloadable(t, cfg)
+	token, err := rl.jwt.GenerateToken(context.Background(), auth.User{ID: 1, Role: "admin"})
+	assert.NoError(t, err)
+
+	// 두 파일은 새 디렉터리에 함께 쓰이고 링크만 바뀐다. 두 번 교체하면 가장 오래된 디렉터리는 지운다.
+	first, err := os.Readlink(filepath.Join(dir, "current"))
+	assert.NoError(t, err)
+	assert.NoError(t, execute(context.Background(), []string{"keys", "rotate"}, io.Discard))
+	assert.NoError(t, execute(context.Background(), []string{"keys", "rotate"}, io.Discard))
+	rotated, err := os.ReadFile(privatePath)
+	assert.NoError(t, err)
+	assert.NotEqual(t, privatePEM, rotated)
+	versions, err := filepath.Glob(filepath.Join(dir, "keys-*"))
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.NotContains(t, versions, filepath.Join(dir, first))
+
+	// 서버가 새 키를 읽어도 교체 전에 발급한 토큰은 유효하다.
+	cfg = newTestConfig(t)
+	assert.NoError(t, rl.Apply(cfg))
+	_, err = rl.jwt.VerifyToken(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestKeys_RotatePlainFiles(t *testing.T) {
+	dir := t.TempDir()
+	privatePEM, publicPEM, err := auth.GenerateKeys(jwa.RS256)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "private.pem"), privatePEM, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "public.pem"), publicPEM, 0o644))
+
+	// 파일을 하나씩 바꿀 수 없으므로 심볼릭 링크가 아니면 거부한다.
+	err = execute(context.Background(), []string{"keys", "rotate", "-private", filepath.Join(dir, "private.pem"), "-public", filepath.Join(dir, "public.pem")}, io.Discard)
+	assert.ErrorContains(t, err, "must be a symlink")
+}
+
+func TestToken_Verify(t *testing.T) {
+	cfg := newTestConfig(t)
+	rl := newTestReloadable(t, cfg)
+	token, err := rl.jwt.GenerateToken(context.Background(), auth.User{ID: 7, Email: "test@example.com", Role: "admin"})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, execute(context.Background(), []string{"token", "verify", token}, &out))
+	assert.Contains(t, out.String(), `"sub": "test@example.com"`)
+	assert.Contains(t, out.String(), `"user_id": 7`)
+
+	assert.ErrorContains(t, execute(context.Background(), []string{"token", "verify", "invalid"}, io.Discard), "failed to verify token")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"pkg/response"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// Check 의존성 하나의 연결 상태를 확인한다.
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+// Health liveness, readiness 프로브를 처리한다.
+// 종료가 시작되면 의존성 상태와 관계없이 not ready 를 반환한다.
+type Health struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type readyResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+func NewHealth(timeout time.Duration, checks ...Check) *Health {
+	return &Health{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Drain 이후의 readiness 요청은 모두 실패한다.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// LivezHandler 프로세스가 요청을 처리할 수 있으면 항상 200 을 반환한다.
+func (h *Health) LivezHandler(w http.ResponseWriter, r *http.Request) {
+	response.SetContentJSON(w)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`{"status": "ok"}`))
+}
+
+// ReadyzHandler 모든 의존성을 동시에 확인하고 의존성별 상태와 지연 시간을 반환한다.
+func (h *Health) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	res := readyResponse{
+		Status: statusOK,
+		Checks: make(map[string]checkResult, len(h.checks)),
+	}
+	if h.draining.Load() {
+		res.Status = statusUnavailable
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.check(r.Context(), c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[c.Name] = result
+			if result.Status != statusOK {
+				res.Status = statusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if res.Status != statusOK {
+		status = http.StatusServiceUnavailable
+	}
+	response.SetContentJSON(w)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (h *Health) check(ctx context.Context, c Check) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Ping(ctx)
+	result := checkResult{
+		Status:    statusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = statusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyz(t *testing.T) {
+	ok := Check{Name: "mysql", Ping: func(ctx context.Context) error { return nil }}
+	down := Check{Name: "redis", Ping: func(ctx context.Context) error { return errors.New("connection refused") }}
+	slow := Check{Name: "redis", Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	tests := map[string]struct {
+		checks   []Check
+		draining bool
+		status   int
+		redis    string
+	}{
+		"ready":    {checks: []Check{ok}, status: http.StatusOK},
+		"down":     {checks: []Check{ok, down}, status: http.StatusServiceUnavailable, redis: "connection refused"},
+		"timeout":  {checks: []Check{ok, slow}, status: http.StatusServiceUnavailable, redis: context.DeadlineExceeded.Error()},
+		"draining": {checks: []Check{ok}, draining: true, status: http.StatusServiceUnavailable},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assertions := assert.New(t)
+
+			h := NewHealth(10*time.Millisecond, tt.checks...)
+			if tt.draining {
+				h.Drain()
+			}
+
+			res := httptest.NewRecorder()
+			h.ReadyzHandler(res, httptest.NewRequest("GET", "/readyz", nil))
+			assertions.Equal(tt.status, res.Code)
+
+			var body readyResponse
+			assertions.NoError(json.NewDecoder(res.Body).Decode(&body))
+			assertions.Equal(statusOK, body.Checks["mysql"].Status)
+			if tt.status == http.StatusOK {
+				assertions.Equal(statusOK, body.Status)
+			} else {
+				assertions.Equal(statusUnavailable, body.Status)
+			}
+			if tt.redis != "" {
+				assertions.Equal(statusUnavailable, body.Checks["redis"].Status)
+				assertions.Equal(tt.redis, body.Checks["redis"].Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pkg/auth"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+)
+
+// runKeys JWT 서명 키를 만든다.
+func runKeys(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "generate":
+		alg := fs.String("alg", "RS256", "signature algorithm")
+		out := fs.String("out", "", "directory to write current/private.pem and current/public.pem. printed when empty")
+		if err := parseNoArgs(fs, args[1:]); err != nil {
+			return err
+		}
+
+		privatePEM, publicPEM, err := generateKeys(*alg)
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			_, err = fmt.Fprintf(w, "%s%s", privatePEM, publicPEM)
+			return err
+		}
+		if err = os.MkdirAll(*out, 0o700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", *out, err)
+		}
+		link := filepath.Join(*out, currentKeys)
+		if err = swapKeys(link, "private.pem", "public.pem", privatePEM, publicPEM); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "wrote %s and %s\n", filepath.Join(link, "private.pem"), filepath.Join(link, "public.pem"))
+		return err
+	case "rotate":
+		// 서버가 JWT_PRIVATE_KEY_FILE, JWT_PUBLIC_KEY_FILE 로 읽는 파일을 바꾼다.
+		// 두 파일은 keys generate -out 이 만든 current 링크 아래에 있어야 한번에 바꿀 수 있다.
+		private := fs.String("private", os.Getenv("JWT_PRIVATE_KEY_FILE"), "private key file")
+		public := fs.String("public", os.Getenv("JWT_PUBLIC_KEY_FILE"), "public key file")
+		if err := parseNoArgs(fs, args[1:]); err != nil {
+			return err
+		}
+		if *private == "" || *public == "" {
+			return fmt.Errorf("-private and -public or JWT_PRIVATE_KEY_FILE and JWT_PUBLIC_KEY_FILE are required\n%w", errUsage)
+		}
+		link := filepath.Dir(*private)
+		if filepath.Dir(*public) != link {
+			return fmt.Errorf("%s and %s must be in the same directory", *private, *public)
+		}
+		if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%s must be a symlink to a key directory. create one with keys generate -out", link)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		privatePEM, publicPEM, err := generateKeys(cfg.JWTSignMethod)
+		if err != nil {
+			return err
+		}
+		if err = swapKeys(link, filepath.Base(*private), filepath.Base(*public), privatePEM, publicPEM); err != nil {
+			return err
+		}
+		// 서버는 교체 전 공개 키를 하나 더 기억하므로 이미 발급한 토큰은 만료될 때까지 유효하다.
+		_, err = fmt.Fprintf(w, "rotated %s and %s. send SIGHUP to auth_service to reload them\n", *private, *public)
+		return err
+	default:
+		return fmt.Errorf("unknown keys command %q\n%w", args[0], errUsage)
+	}
+}
+
+func generateKeys(alg string) (privatePEM, publicPEM []byte, err error) {
+	var signMethod jwa.SignatureAlgorithm
+	if err := signMethod.Accept(alg); err != nil {
+		return nil, nil, fmt.Errorf("invalid JWT sign method: %w", err)
+	}
+	return auth.GenerateKeys(signMethod)
+}
+
+// currentKeys 서버가 읽는 키 디렉터리를 가리키는 심볼릭 링크
+const currentKeys = "current"
+
+// swapKeys 키 쌍을 새 디렉터리에 모두 쓴 뒤 link 를 한 번의 rename 으로 바꾼다.
+// 파일을 하나씩 바꾸면 그 사이에 설정을 다시 읽은 서버가 짝이 맞지 않는 키를 읽는다.
+// 교체 직전 디렉터리는 남기고 그보다 오래된 디렉터리는 지운다.
+func swapKeys(link, privateName, publicName string, privatePEM, publicPEM []byte) error {
+	root := filepath.Dir(link)
+	dir, err := os.MkdirTemp(root, "keys-")
+	if err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	tmp := filepath.Join(root, "."+filepath.Base(dir))
+	err = os.WriteFile(filepath.Join(dir, privateName), privatePEM, 0o600)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, publicName), publicPEM, 0o644)
+	}
+	if err == nil {
+		err = os.Symlink(filepath.Base(dir), tmp)
+	}
+	previous, _ := os.Readlink(link)
+	if err == nil {
+		err = os.Rename(tmp, link)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("failed to write keys to %s: %w", link, err)
+	}
+
+	old, _ := filepath.Glob(filepath.Join(root, "keys-*"))
+	for _, path := range old {
+		if name := filepath.Base(path); name != filepath.Base(dir) && name != previous {
+			_ = os.RemoveAll(path)
+		}
+	}
+	return nil
+}
@@ -2,44 +2,147 @@ package main
 
 import (
 	"auth_service/config"
+	"auth_service/internal/outbox"
+	"auth_service/internal/repository"
+	"auth_service/internal/service"
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
-	"pkg/mysqlconn"
+	"os"
+	"pkg/clock"
+	"pkg/lifecycle"
+	"pkg/logger"
+	"pkg/metrics"
+	"pkg/middleware"
 	"pkg/redisclient"
-)
+	"pkg/tracing"
 
-const postMethod = "POST"
+	"golang.org/x/sync/errgroup"
+)
 
 func main() {
-	if err := run(context.Background()); err != nil {
-		log.Fatalf("failed to run: %v", err)
+	if err := execute(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		slog.Error("failed to run", "error", err)
+		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context) error {
+func run(ctx context.Context) (err error) {
 	cfg, err := config.New()
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	rl, err := NewReloadable(cfg, clock.Real{})
+	if err != nil {
+		return err
+	}
+	l := logger.New(os.Stdout, cfg.Env, rl.level)
+	slog.SetDefault(l)
+	ctx = logger.WithContext(ctx, l)
+	l.Debug("loaded config", "config", cfg)
+
+	// 시작한 순서의 역순으로 정리한다. HTTP 서버는 Server.Run 이 먼저 종료한다.
+	lc := lifecycle.New()
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		err = errors.Join(err, lc.Close(closeCtx))
+	}()
+
+	// 표준 출력의 JSON 로그와 섞이지 않도록 span 은 표준 에러에 쓴다.
+	shutdownTracing, err := tracing.Setup(cfg.TraceExporter, "auth_service", os.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	lc.Add("tracing", shutdownTracing)
+
+	tlsConfig, reloader, err := newTLSConfig(cfg)
 	if err != nil {
-		log.Fatalf("failed to listen port %d: %v", cfg.Port, err)
+		return fmt.Errorf("failed to setup tls: %w", err)
+	}
+
+	if cfg.DbMigrate {
+		if err := migrateUp(ctx, cfg); err != nil {
+			return fmt.Errorf("failed to migrate: %w", err)
+		}
 	}
 
-	mc, err := mysqlconn.New(cfg.DbUser, cfg.DbPw, cfg.DbHost, cfg.DbPort, cfg.DbName)
+	mc, err := newMySQLConn(cfg)
 	if err != nil {
-		log.Fatalf("failed to connect mc: %v", err)
+		return fmt.Errorf("failed to connect mysql: %w", err)
 	}
-	defer func(mc *mysqlconn.MySQLConn) {
-		_ = mc.Close()
-	}(mc)
+	lc.AddCloser("mysql", mc)
 
 	rc := redisclient.New(cfg.RedisHost, cfg.RedisPort, cfg.RedisPw, 0)
-	mux := NewMux(mc, rc)
+	lc.AddCloser("redis", rc)
 
-	s := NewServer(listener, mux)
-	return s.Run(ctx)
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen port %d: %w", cfg.Port, err)
+	}
+
+	reg := metrics.NewRegistry()
+	health := NewHealth(cfg.ReadinessTimeout,
+		Check{Name: "mysql", Ping: mc.Ping},
+		Check{Name: "redis", Ping: rc.Ping},
+	)
+	relay := outbox.NewRelay(mc.Conn(), repository.NewOutboxRepository(), rc, outbox.NewLogPublisher(),
+		cfg.OutboxPollInterval, cfg.OutboxBatchSize)
+	mux, err := NewMux(cfg, mc, rc, relay, reg, health, rl)
+	if err != nil {
+		return err
+	}
+
+	// 게스트 정리는 서버가 종료되면 함께 멈춘다.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cleaner := service.NewGuestCleaner(mc.Conn(), repository.NewUserRepository(), cfg.GuestCleanupInterval, cfg.GuestTTL)
+
+	opts := []ServerOption{
+		WithLimits(cfg),
+		WithDrain(health, cfg.ShutdownDrainDelay),
+		WithShutdownTimeout(cfg.ShutdownTimeout),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, WithTLS(tlsConfig))
+	}
+
+	s := NewServer(listener, middleware.Chain(
+		mux.ServeHTTP,
+		// mux 를 직접 감싸야 하는 미들웨어
+		TraceRoute(),
+		MaxBytes(cfg.MaxBodyBytes),
+		// preflight 요청은 mux 가 405 로 거부하기 전에 처리한다.
+		rl.cors.Middleware(),
+		Metrics(metrics.NewHTTP(reg)),
+		Logging(l),
+		Tracing(),
+		RequestID(),
+	), opts...)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		return cleaner.Run(ctx)
+	})
+	eg.Go(func() error {
+		return mc.Run(ctx, cfg.DbReplicaCheckInterval)
+	})
+	eg.Go(func() error {
+		return relay.Run(ctx)
+	})
+	eg.Go(func() error {
+		return rl.Run(ctx, cfg.File, cfg.ConfigReloadInterval)
+	})
+	if reloader != nil {
+		eg.Go(func() error {
+			return reloader.Run(ctx, cfg.TLSReloadInterval)
+		})
+	}
+	eg.Go(func() error {
+		defer cancel()
+		return s.Run(ctx)
+	})
+	return eg.Wait()
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun_InvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		key      string
+		value    string
+		expected string
+	}{
+		"config":    {key: "PORT", value: "http", expected: "failed to load config"},
+		"log level": {key: "LOG_LEVEL", value: "verbose", expected: "LOG_LEVEL must be one of"},
+		"tracing":   {key: "TRACE_EXPORTER", value: "jaeger", expected: "TRACE_EXPORTER must be one of"},
+		"tls":       {key: "TLS_CERT_FILE", value: "tls.crt", expected: "TLS_CERT_FILE and TLS_KEY_FILE must be set together"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv(tt.key, tt.value)
+
+			err := run(context.Background())
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
@@ -2,24 +2,133 @@ package main
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"pkg/ctxkey"
+	"pkg/logger"
+	"pkg/metrics"
+	"pkg/middleware"
+	"pkg/mysqlconn"
+	"pkg/response"
+	"runtime/debug"
 	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Timeout(timeout time.Duration) middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
 )
 
-type Middleware func(http.HandlerFunc) http.HandlerFunc
+// RequestID 클라이언트가 보낸 X-Request-ID 를 사용하고 없거나 올바르지 않으면 새로 만든다.
+// 요청 ID는 컨텍스트에 저장하고 응답 헤더로 돌려준다.
+func RequestID() middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+			w.Header().Set(requestIDHeader, id)
+			next(w, r.WithContext(ctxkey.WithRequestID(r.Context(), id)))
+		}
+	}
+}
 
-func Chain(f http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
-	for _, m := range middlewares {
-		f = m(f)
+// validRequestID 로그와 헤더에 그대로 쓸 수 있는 출력 가능한 ASCII 만 허용한다.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
 	}
-	return f
+	return true
 }
 
-func Method(m string) Middleware {
+// Logging 요청마다 컨텍스트 로거를 만들고 응답이 끝나면 요청 로그를 남긴다.
+// 요청 ID와 trace ID를 기록하려면 RequestID, Tracing 보다 안쪽에 있어야 한다.
+func Logging(l *slog.Logger) middleware.Middleware {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != m {
-				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			start := time.Now()
+			requestID, _ := ctxkey.RequestID(r.Context())
+			rl := l.With(
+				"request_id", requestID,
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				rl = rl.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+			}
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next(rec, r.WithContext(logger.WithContext(r.Context(), rl)))
+
+			rl.Info("request",
+				"status", rec.status,
+				"latency", time.Since(start),
+				"bytes", rec.bytes,
+			)
+		}
+	}
+}
+
+// Recover 핸들러의 panic 을 복구하고 스택 트레이스를 로그로 남긴 뒤 500 을 반환한다.
+// 요청 ID가 기록되도록 Logging 안쪽에 있어야 한다.
+func Recover(m *metrics.Panics) middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				// 응답을 중단하려는 panic 은 net/http 가 처리하도록 그대로 전달한다.
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				m.Inc(middleware.Route(r))
+				logger.FromContext(r.Context()).Error("panic recovered",
+					"panic", v,
+					"stack", string(debug.Stack()),
+				)
+				if !rec.wroteHeader {
+					response.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next(rec, r)
+		}
+	}
+}
+
+// RequireClientCert 검증된 클라이언트 인증서가 없는 요청을 거부한다. 내부 라우트에 사용한다.
+func RequireClientCert() middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				response.Error(w, "client certificate required", http.StatusForbidden)
 				return
 			}
 			next(w, r)
@@ -27,25 +136,111 @@ func Method(m string) Middleware {
 	}
 }
 
-func Timeout(timeout time.Duration) Middleware {
+// ReadYourWrites 요청이 primary 에 쓴 뒤의 조회는 복제 지연이 없도록 primary 로 보낸다.
+func ReadYourWrites() middleware.Middleware {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			ctx, cancel := context.WithTimeout(r.Context(), timeout)
-			defer cancel()
-			next(w, r.WithContext(ctx))
+			next(w, r.WithContext(mysqlconn.WithReadYourWrites(r.Context())))
 		}
 	}
 }
 
-const nowKey = "time"
+// MaxBytes 요청 본문을 n 바이트로 제한한다.
+// Content-Length 로 초과가 확인되면 바로 413 을 반환하고, 그 외에는 본문을 읽을 때 실패한다.
+// Metrics 가 ServeMux 와 같은 요청을 보도록 요청을 복사하지 않고 Body 만 바꾼다.
+func MaxBytes(n int64) middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				response.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next(w, r)
+		}
+	}
+}
 
-func TimeNow() Middleware {
+// Tracing traceparent 헤더에서 상위 span 을 이어받아 요청마다 서버 span 을 만든다.
+func Tracing() middleware.Middleware {
+	tracer := otel.Tracer("auth_service/cmd")
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			now := time.Now()
-			ctx := context.WithValue(r.Context(), nowKey, now)
-			next(w, r.WithContext(ctx))
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next(rec, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+		}
+	}
+}
+
+// TraceRoute 요청이 매칭된 라우트로 span 이름을 바꾼다.
+// ServeMux 가 요청에 설정하는 r.Pattern 을 읽어야 하므로 mux 를 직접 감싸야 한다.
+func TraceRoute() middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r)
+
+			if route := middleware.Route(r); route != "" {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(attribute.String("http.route", route))
+			}
 		}
 	}
+}
+
+// Metrics 라우트별 요청 수와 지연 시간을 기록한다.
+// ServeMux 가 요청에 설정하는 r.Pattern 을 읽어야 하므로 mux 를 직접 감싸야 한다.
+func Metrics(m *metrics.HTTP) middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next(rec, r)
+
+			m.Observe(middleware.Route(r), r.Method, rec.status, time.Since(start))
+		}
+	}
+}
+
+// responseRecorder 응답 상태 코드와 크기를 기록한다.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
 
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"pkg/ctxkey"
+	"pkg/logger"
+	"pkg/metrics"
+	"pkg/middleware"
+	"pkg/response"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestLogging(t *testing.T) {
+	assertions := assert.New(t)
+
+	var buf bytes.Buffer
+	l := logger.New(&buf, "prod", slog.LevelInfo)
+
+	h := middleware.Chain(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("in handler")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}, Logging(l), RequestID())
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/register", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	h(res, req)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assertions.Len(lines, 2)
+
+	var handlerLog, requestLog map[string]any
+	assertions.NoError(json.Unmarshal(lines[0], &handlerLog))
+	assertions.NoError(json.Unmarshal(lines[1], &requestLog))
+
+	assertions.Equal("req-1", handlerLog["request_id"])
+	assertions.Equal("req-1", requestLog["request_id"])
+	assertions.Equal("POST", requestLog["method"])
+	assertions.Equal("/register", requestLog["path"])
+	assertions.Equal(float64(http.StatusCreated), requestLog["status"])
+	assertions.Equal(float64(5), requestLog["bytes"])
+	assertions.Contains(requestLog, "latency")
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	h := middleware.Chain(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ctxkey.RequestID(r.Context())
+	}, RequestID())
+
+	tests := map[string]struct {
+		header   string
+		expected string
+	}{
+		"accept":   {header: "req-1", expected: "req-1"},
+		"generate": {header: ""},
+		"invalid":  {header: "bad id\n"},
+		"too long": {header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/health", nil)
+			req.Header.Set("X-Request-ID", tt.header)
+			h(res, req)
+
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, res.Header().Get("X-Request-ID"))
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, got)
+			} else {
+				assert.NotEqual(t, tt.header, got)
+			}
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	assertions := assert.New(t)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+	})
+
+	var buf bytes.Buffer
+	l := logger.New(&buf, "prod", slog.LevelInfo)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	h := middleware.Chain(mux.ServeHTTP, TraceRoute(), Logging(l), Tracing(), RequestID())
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h(res, req)
+
+	spans := sr.Ended()
+	assertions.Len(spans, 1)
+	span := spans[0]
+	assertions.Equal("GET /users/{id}", span.Name())
+	assertions.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assertions.Equal("00f067aa0ba902b7", span.Parent().SpanID().String())
+	assertions.Contains(span.Attributes(), attribute.String("http.route", "/users/{id}"))
+	assertions.Contains(span.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+	assertions.Equal(codes.Error, span.Status().Code)
+
+	var requestLog map[string]any
+	assertions.NoError(json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &requestLog))
+	assertions.Equal(span.SpanContext().TraceID().String(), requestLog["trace_id"])
+	assertions.Equal(span.SpanContext().SpanID().String(), requestLog["span_id"])
+}
+
+func TestMaxBytes(t *testing.T) {
+	h := middleware.Chain(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			response.DecodeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}, MaxBytes(8))
+
+	tests := map[string]struct {
+		body          string
+		contentLength int64
+		expected      int
+	}{
+		"within limit":           {body: "12345678", contentLength: 8, expected: http.StatusOK},
+		"content length exceeds": {body: "123456789", contentLength: 9, expected: http.StatusRequestEntityTooLarge},
+		// chunked 요청처럼 길이를 모르는 경우에는 읽을 때 제한된다.
+		"unknown length exceeds": {body: "123456789", contentLength: -1, expected: http.StatusRequestEntityTooLarge},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/register", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			h(res, req)
+
+			assert.Equal(t, tt.expected, res.Code)
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	assertions := assert.New(t)
+
+	var buf bytes.Buffer
+	l := logger.New(&buf, "prod", slog.LevelInfo)
+	reg := prometheus.NewRegistry()
+	panics := metrics.NewPanics(reg)
+
+	mux := http.NewServeMux()
+	root := middleware.NewGroup(mux, Recover(panics))
+	root.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	h := middleware.Chain(mux.ServeHTTP, Logging(l), RequestID())
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	h(res, req)
+
+	assertions.Equal(http.StatusInternalServerError, res.Code)
+	assertions.JSONEq(`{"message": "Internal Server Error"}`, res.Body.String())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assertions.Len(lines, 2)
+	var panicLog map[string]any
+	assertions.NoError(json.Unmarshal(lines[0], &panicLog))
+	assertions.Equal("panic recovered", panicLog["msg"])
+	assertions.Equal("req-1", panicLog["request_id"])
+	assertions.Equal("boom", panicLog["panic"])
+	assertions.Contains(panicLog["stack"], "runtime/debug.Stack")
+
+	assertions.NoError(testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_panics_total Number of recovered handler panics by route.
+# TYPE http_panics_total counter
+http_panics_total{route="/login"} 1
+`), "http_panics_total"))
+}
+
+func TestRecover_Abort(t *testing.T) {
+	h := middleware.Chain(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}, Recover(metrics.NewPanics(prometheus.NewRegistry())))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
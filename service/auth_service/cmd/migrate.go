@@ -0,0 +1,93 @@
+package main
+
+import (
+	"auth_service/config"
+	"auth_service/migration"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"pkg/migrate"
+	"pkg/mysqlconn"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down | goto <version> | version"
+
+// runMigrate 스키마를 변경하고 종료한다. 여러 인스턴스가 동시에 실행해도 잠금으로 하나씩 적용한다.
+func runMigrate(ctx context.Context, args []string, w io.Writer) (err error) {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	var target uint64
+	switch args[0] {
+	case "up", "down", "version":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+	case "goto":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		if target, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	m, closeDB, err := newMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeDB())
+	}()
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "goto":
+		return m.Goto(ctx, target)
+	default:
+		version, dirty, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "version %d, dirty %t\n", version, dirty)
+		return err
+	}
+}
+
+// newMigrator 마이그레이션 파일은 여러 문장을 담을 수 있어 요청을 처리하는 연결과 따로 연다.
+func newMigrator(cfg *config.Config) (*migrate.Migrator, func() error, error) {
+	mc, err := newMySQLConn(cfg, mysqlconn.WithReplicas(), mysqlconn.WithPool(1, 1), mysqlconn.WithMultiStatements())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect mysql: %w", err)
+	}
+	m, err := migrate.New(mc.Conn().DB, migration.FS)
+	if err != nil {
+		_ = mc.Close()
+		return nil, nil, err
+	}
+	return m, mc.Close, nil
+}
+
+// migrateUp 서버를 시작하기 전에 남은 마이그레이션을 적용한다.
+func migrateUp(ctx context.Context, cfg *config.Config) (err error) {
+	m, closeDB, err := newMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeDB())
+	}()
+	return m.Up(ctx)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"auth_service/migration"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"pkg/migrate"
+)
+
+func TestRunMigrate_Usage(t *testing.T) {
+	tests := map[string][]string{
+		"no command":      nil,
+		"unknown command": {"force"},
+		"missing version": {"goto"},
+		"extra argument":  {"up", "1"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, runMigrate(context.Background(), args, io.Discard), migrateUsage)
+		})
+	}
+
+	assert.ErrorContains(t, runMigrate(context.Background(), []string{"goto", "latest"}, io.Discard), "invalid version")
+}
+
+func TestMigrationFS(t *testing.T) {
+	migrations, err := migrate.Load(migration.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		// 버전은 빠짐없이 1 씩 증가하고 모든 변경은 되돌릴 수 있어야 한다.
+		assert.Equal(t, uint64(i+1), m.Version)
+		assert.NotEmpty(t, m.Up, m.Name)
+		assert.NotEmpty(t, m.Down, m.Name)
+		assert.NotContains(t, m.Up, "`auth`.", m.Name)
+	}
+}
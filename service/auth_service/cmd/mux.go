@@ -1,52 +1,96 @@
 package main
 
 import (
+	"auth_service/config"
 	"auth_service/internal/handler"
+	"auth_service/internal/mailer"
+	authmetrics "auth_service/internal/metrics"
+	"auth_service/internal/outbox"
+	"auth_service/internal/password"
 	"auth_service/internal/repository"
 	"auth_service/internal/service"
+	"fmt"
 	"net/http"
-	"pkg/auth"
+	"pkg/metrics"
+	"pkg/middleware"
 	"pkg/mysqlconn"
 	"pkg/redisclient"
 	"time"
 
-	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-func NewMux(mc *mysqlconn.MySQLConn, rc *redisclient.RedisClient) *http.ServeMux {
+// NewMux JWT 생성기와 요청 제한은 설정을 다시 읽을 때 바뀌도록 rl 의 것을 사용한다.
+// 세션과 이벤트는 relay 가 커밋된 outbox 에서 읽어 적용한다.
+func NewMux(cfg *config.Config, mc *mysqlconn.MySQLConn, rc *redisclient.RedisClient, relay *outbox.Relay, reg *prometheus.Registry, health *Health, rl *Reloadable) (*http.ServeMux, error) {
 	userRepo := repository.NewUserRepository()
+	// 토큰의 iat, exp 와 last_login, 세션 만료 시각이 같은 시계를 따르도록 공유한다.
+	clk := rl.clock
 
-	// JWT 생성기 설정
-	jwtConfig := auth.JWTConfig{
-		Issuer:     "auth_service",
-		ExpiresIn:  24 * time.Hour,
-		SignMethod: jwa.RS256,
+	hasher, err := newHasher(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize password hasher: %w", err)
 	}
 
-	jwtGenerator, err := auth.NewJWTManager(jwtConfig)
-	if err != nil {
-		panic("failed to initialize JWT generator: " + err.Error())
+	if db := mc.Conn(); db != nil {
+		metrics.RegisterDBStats(reg, db.DB, "auth")
 	}
+	metrics.RegisterRedisPoolStats(reg, rc)
 
-	authService := service.NewAuthService(mc.Conn(), userRepo, rc, jwtGenerator)
+	opts := []service.Option{
+		service.WithClock(clk),
+		service.WithSessionExpire(cfg.SessionExpire),
+		service.WithDBRouter(mc),
+		service.WithMetrics(authmetrics.NewAuth(reg)),
+		service.WithOutboxNotifier(relay),
+	}
+	if cfg.ConcealRegistration {
+		opts = append(opts, service.WithConcealedRegistration(mailer.NewLogMailer()))
+	}
+
+	authService := service.NewAuthService(mc.Conn(), userRepo, repository.NewOutboxRepository(), rl.jwt, hasher, opts...)
 	authHandler := handler.NewAuthHandler(authService)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	root := middleware.NewGroup(mux, Recover(metrics.NewPanics(reg)))
+
+	// /health 는 기존 프로브 설정을 위해 /livez 와 같게 유지한다.
+	root.HandleFunc("GET /health", health.LivezHandler)
+	root.HandleFunc("GET /livez", health.LivezHandler)
+	root.HandleFunc("GET /readyz", health.ReadyzHandler)
+
+	// 클라이언트 CA 가 설정되면 내부 라우트는 mTLS 로만 접근할 수 있다.
+	var internalMiddlewares []middleware.Middleware
+	if cfg.TLSClientCAFile != "" {
+		internalMiddlewares = append(internalMiddlewares, RequireClientCert())
+	}
+	internal := root.Group("", internalMiddlewares...)
+	internal.Handle("GET /metrics", metrics.Handler(reg))
+
+	authMiddlewares := []middleware.Middleware{Timeout(5 * time.Second), rl.limiter.Middleware()}
+	if cfg.DbReadYourWrites {
+		authMiddlewares = append(authMiddlewares, ReadYourWrites())
+	}
+	// 버전이 없는 경로는 기존 클라이언트를 위해 남겨둔다.
+	registerAuthRoutes(root.Group("", authMiddlewares...), authHandler)
+	registerAuthRoutes(root.Group("/v1", authMiddlewares...), authHandler)
+	return mux, nil
+}
+
+func newHasher(cfg *config.Config) (*password.Hasher, error) {
+	return password.New(password.Config{
+		Algorithm:     cfg.PasswordAlgorithm,
+		BcryptCost:    cfg.BcryptCost,
+		Argon2Time:    cfg.Argon2Time,
+		Argon2Memory:  cfg.Argon2Memory,
+		Argon2Threads: cfg.Argon2Threads,
 	})
-	mux.HandleFunc("/register", Chain(
-		authHandler.RegisterHandler,
-		Method(postMethod),
-		Timeout(5*time.Second),
-		TimeNow(),
-	))
-	mux.HandleFunc("/login", Chain(
-		authHandler.LoginHandler,
-		Method(postMethod),
-		Timeout(5*time.Second),
-		TimeNow(),
-	))
-	return mux
+}
+
+func registerAuthRoutes(g *middleware.Group, h *handler.AuthHandler) {
+	g.HandleFunc("POST /register", h.RegisterHandler)
+	g.HandleFunc("POST /login", h.LoginHandler)
+	g.HandleFunc("POST /register/guest", h.RegisterGuestHandler)
+	g.HandleFunc("POST /register/upgrade", h.UpgradeGuestHandler)
+	g.HandleFunc("POST /login/guest", h.LoginGuestHandler)
 }
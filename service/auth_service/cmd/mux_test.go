@@ -1,24 +1,87 @@
 package main
 
 import (
+	"auth_service/config"
+	"auth_service/internal/outbox"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 
+	"pkg/clock"
+	"pkg/metrics"
+	"pkg/middleware"
 	"pkg/mysqlconn"
 	"pkg/redisclient"
 )
 
+// newTestConfig 연결하지 않는 테스트에서도 필수 값 검증을 통과하도록 환경 변수를 채운다.
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	setRequiredEnv(t)
+
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatalf("cannot create config: %v", err)
+	}
+	return cfg
+}
+
+func newTestReloadable(t *testing.T, cfg *config.Config) *Reloadable {
+	t.Helper()
+	rl, err := NewReloadable(cfg, clock.Real{})
+	if err != nil {
+		t.Fatalf("cannot create reloadable: %v", err)
+	}
+	return rl
+}
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	for k, v := range map[string]string{
+		"DB_USER":    "auth",
+		"DB_HOST":    "localhost",
+		"DB_PORT":    "3306",
+		"DB_NAME":    "auth",
+		"REDIS_HOST": "localhost",
+		"REDIS_PORT": "6379",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+// newTestMux 연결하지 않는 의존성으로 mux 를 만든다.
+func newTestMux(t *testing.T, cfg *config.Config, reg *prometheus.Registry) *http.ServeMux {
+	t.Helper()
+	mux, err := NewMux(cfg, &mysqlconn.MySQLConn{}, &redisclient.RedisClient{}, outbox.NewRelay(nil, nil, nil, nil, time.Second, 1), reg, NewHealth(time.Second), newTestReloadable(t, cfg))
+	if err != nil {
+		t.Fatalf("cannot create mux: %v", err)
+	}
+	return mux
+}
+
+func TestNewMux_InvalidHasher(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.PasswordAlgorithm = "md5"
+
+	_, err := NewMux(cfg, &mysqlconn.MySQLConn{}, &redisclient.RedisClient{}, outbox.NewRelay(nil, nil, nil, nil, time.Second, 1), prometheus.NewRegistry(), NewHealth(time.Second), newTestReloadable(t, cfg))
+	assert.ErrorContains(t, err, "password hasher")
+}
+
 func TestHealth(t *testing.T) {
 	assertions := assert.New(t)
 
 	res := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/health", nil)
 
-	mux := NewMux(&mysqlconn.MySQLConn{}, &redisclient.RedisClient{})
+	cfg := newTestConfig(t)
+
+	mux := newTestMux(t, cfg, prometheus.NewRegistry())
 	mux.ServeHTTP(res, req)
 
 	assertions.Equal(http.StatusOK, res.Code)
@@ -26,3 +89,53 @@ func TestHealth(t *testing.T) {
 	data, _ := io.ReadAll(res.Body)
 	assertions.Equal(`{"status": "ok"}`, string(data))
 }
+
+func TestMetrics(t *testing.T) {
+	assertions := assert.New(t)
+
+	cfg := newTestConfig(t)
+
+	reg := prometheus.NewRegistry()
+	mux := newTestMux(t, cfg, reg)
+	h := middleware.Chain(mux.ServeHTTP, Metrics(metrics.NewHTTP(reg)))
+
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	res := httptest.NewRecorder()
+	h(res, httptest.NewRequest("GET", "/metrics", nil))
+	assertions.Equal(http.StatusOK, res.Code)
+
+	data, _ := io.ReadAll(res.Body)
+	body := string(data)
+	assertions.Contains(body, `http_requests_total{method="GET",route="/health",status="200"} 1`)
+	assertions.Contains(body, "auth_registrations_total 0")
+	assertions.Contains(body, "auth_tokens_issued_total 0")
+}
+
+func TestRoutes(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	mux := newTestMux(t, cfg, prometheus.NewRegistry())
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		expected int
+		allow    string
+	}{
+		"v1":           {method: "POST", path: "/v1/login", expected: http.StatusBadRequest},
+		"unversioned":  {method: "POST", path: "/login", expected: http.StatusBadRequest},
+		"wrong method": {method: "GET", path: "/v1/login", expected: http.StatusMethodNotAllowed, allow: "POST"},
+		"probe method": {method: "POST", path: "/livez", expected: http.StatusMethodNotAllowed, allow: "GET, HEAD"},
+		"unknown":      {method: "POST", path: "/v2/login", expected: http.StatusNotFound},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			mux.ServeHTTP(res, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{")))
+
+			assert.Equal(t, tt.expected, res.Code)
+			assert.Equal(t, tt.allow, res.Header().Get("Allow"))
+		})
+	}
+}
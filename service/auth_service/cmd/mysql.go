@@ -0,0 +1,26 @@
+package main
+
+import (
+	"auth_service/config"
+	"pkg/mysqlconn"
+	"time"
+)
+
+// newMySQLConn 설정 조합은 config.Validate 에서 확인한다. opts 는 설정보다 나중에 적용된다.
+func newMySQLConn(cfg *config.Config, opts ...mysqlconn.Option) (*mysqlconn.MySQLConn, error) {
+	loc, err := time.LoadLocation(cfg.DbLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	return mysqlconn.New(cfg.DbUser, cfg.DbPw, cfg.DbHost, cfg.DbPort, cfg.DbName, append([]mysqlconn.Option{
+		mysqlconn.WithPool(cfg.DbMaxOpenConns, cfg.DbMaxIdleConns),
+		mysqlconn.WithConnLifetime(cfg.DbConnMaxLifetime, cfg.DbConnMaxIdleTime),
+		mysqlconn.WithTimeouts(cfg.DbDialTimeout, cfg.DbReadTimeout, cfg.DbWriteTimeout),
+		mysqlconn.WithTLS(cfg.DbTLS),
+		mysqlconn.WithCharset(cfg.DbCharset, cfg.DbCollation),
+		mysqlconn.WithLocation(loc),
+		mysqlconn.WithConnectRetry(cfg.DbConnectTimeout, 500*time.Millisecond, 5*time.Second),
+		mysqlconn.WithReplicas(cfg.DbReplicas...),
+	}, opts...)...)
+}
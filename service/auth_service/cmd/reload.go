@@ -0,0 +1,163 @@
+package main
+
+import (
+	"auth_service/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"pkg/auth"
+	"pkg/clock"
+	"pkg/middleware"
+	"syscall"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+)
+
+// Reloadable 재시작 없이 바꿀 수 있는 설정을 보관한다.
+// 값마다 원자적으로 교체되므로 요청은 이전 값이나 새 값 중 하나만 본다.
+type Reloadable struct {
+	level   *slog.LevelVar
+	limiter *middleware.RateLimiter
+	cors    *middleware.CORSPolicy
+	jwt     *auth.JWTManager
+	clock   clock.Clock
+}
+
+func NewReloadable(cfg *config.Config, clk clock.Clock) (*Reloadable, error) {
+	level, err := parseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var signMethod jwa.SignatureAlgorithm
+	if err := signMethod.Accept(cfg.JWTSignMethod); err != nil {
+		return nil, fmt.Errorf("invalid JWT sign method: %w", err)
+	}
+
+	// 발급자, 만료 시간, 서명 방식은 재시작해야 바뀐다.
+	jwt, err := auth.NewJWTManager(auth.JWTConfig{
+		Issuer:     cfg.JWTIssuer,
+		ExpiresIn:  cfg.JWTExpiresIn,
+		SignMethod: signMethod,
+		PrivateKey: []byte(cfg.JWTPrivateKey),
+		PublicKey:  []byte(cfg.JWTPublicKey),
+		Clock:      clk,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize JWT generator: %w", err)
+	}
+
+	r := &Reloadable{
+		level: &slog.LevelVar{},
+		// 버전과 관계없이 클라이언트마다 하나의 한도를 공유한다.
+		limiter: middleware.NewRateLimiter(rateLimit(cfg)),
+		cors:    middleware.NewCORSPolicy(corsConfig(cfg)),
+		jwt:     jwt,
+		clock:   clk,
+	}
+	r.level.Set(level)
+	return r, nil
+}
+
+// Apply 새 설정을 적용한다. 실패할 수 있는 단계를 먼저 처리해서 오류가 나면 아무것도 바꾸지 않는다.
+func (r *Reloadable) Apply(cfg *config.Config) error {
+	level, err := parseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	if err := r.jwt.SetKeys([]byte(cfg.JWTPrivateKey), []byte(cfg.JWTPublicKey)); err != nil {
+		return fmt.Errorf("failed to set JWT keys: %w", err)
+	}
+
+	r.limiter.SetLimit(rateLimit(cfg))
+	r.cors.Set(corsConfig(cfg))
+	r.level.Set(level)
+	return nil
+}
+
+// Run SIGHUP 을 받거나 설정 파일이 바뀌면 설정을 다시 읽는다. 잘못된 설정이면 기존 값을 유지한다.
+func (r *Reloadable) Run(ctx context.Context, path string, interval time.Duration) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// 설정 파일이 없으면 신호만 기다린다.
+	var tick <-chan time.Time
+	if path != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	last := stat(path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.reload("signal")
+		case <-tick:
+			current := stat(path)
+			if current == last {
+				continue
+			}
+			last = current
+			r.reload("file")
+		}
+	}
+}
+
+func (r *Reloadable) reload(trigger string) {
+	cfg, err := config.New()
+	if err == nil {
+		err = r.Apply(cfg)
+	}
+	if err != nil {
+		slog.Error("failed to reload config, keeping the current one", "trigger", trigger, "error", err)
+		return
+	}
+	slog.Info("reloaded config", "trigger", trigger, "log_level", cfg.LogLevel)
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// stat 파일을 읽을 수 없으면 빈 값을 반환해서 다시 생기면 바뀐 것으로 본다.
+func stat(path string) fileState {
+	if path == "" {
+		return fileState{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
+
+func rateLimit(cfg *config.Config) middleware.RateLimit {
+	return middleware.RateLimit{RPS: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst}
+}
+
+func corsConfig(cfg *config.Config) middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
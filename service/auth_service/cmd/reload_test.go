@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pkg/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/errgroup"
+)
+
+func corsOrigin(rl *Reloadable, origin string) string {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/login", nil)
+	req.Header.Set("Origin", origin)
+	rl.cors.Middleware()(func(w http.ResponseWriter, r *http.Request) {})(res, req)
+	return res.Header().Get("Access-Control-Allow-Origin")
+}
+
+// limitedStatus 같은 클라이언트가 연달아 보낸 두 요청 중 두 번째의 상태 코드
+func limitedStatus(rl *Reloadable) int {
+	handler := rl.limiter.Middleware()(func(w http.ResponseWriter, r *http.Request) {})
+	res := httptest.NewRecorder()
+	for range 2 {
+		res = httptest.NewRecorder()
+		handler(res, httptest.NewRequest("POST", "/login", nil))
+	}
+	return res.Code
+}
+
+func TestReloadable_Apply(t *testing.T) {
+	assertions := assert.New(t)
+
+	cfg := newTestConfig(t)
+	rl := newTestReloadable(t, cfg)
+
+	next := *cfg
+	next.LogLevel = "debug"
+	next.CORSAllowedOrigins = []string{"https://example.com"}
+	next.RateLimitRPS, next.RateLimitBurst = 1, 1
+	assertions.NoError(rl.Apply(&next))
+	assertions.Equal(slog.LevelDebug, rl.level.Level())
+	assertions.Equal("https://example.com", corsOrigin(rl, "https://example.com"))
+	assertions.Equal(http.StatusTooManyRequests, limitedStatus(rl))
+
+	// 잘못된 설정은 어떤 값도 바꾸지 않는다.
+	invalid := next
+	invalid.LogLevel = "warn"
+	invalid.CORSAllowedOrigins = []string{"https://other.com"}
+	invalid.RateLimitRPS = 0
+	invalid.JWTPrivateKey, invalid.JWTPublicKey = "invalid", "invalid"
+	assertions.Error(rl.Apply(&invalid))
+	assertions.Equal(slog.LevelDebug, rl.level.Level())
+	assertions.Equal("https://example.com", corsOrigin(rl, "https://example.com"))
+	assertions.Empty(corsOrigin(rl, "https://other.com"))
+	assertions.Equal(http.StatusTooManyRequests, limitedStatus(rl))
+
+	invalid = next
+	invalid.LogLevel = "verbose"
+	assertions.Error(rl.Apply(&invalid))
+	assertions.Equal(slog.LevelDebug, rl.level.Level())
+
+	// 설정된 서명 방식으로 서명할 수 없는 키는 교체하지 않는다.
+	privatePEM, publicPEM, err := generateKeys("ES256")
+	assertions.NoError(err)
+	invalid = next
+	invalid.JWTPrivateKey, invalid.JWTPublicKey = string(privatePEM), string(publicPEM)
+	assertions.ErrorContains(rl.Apply(&invalid), "RS256")
+	_, err = rl.jwt.GenerateToken(context.Background(), auth.User{ID: 1})
+	assertions.NoError(err)
+}
+
+func TestReloadable_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	write("LOG_LEVEL: info\n")
+	t.Setenv("CONFIG_FILE", path)
+	cfg := newTestConfig(t)
+	rl := newTestReloadable(t, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		return rl.Run(ctx, path, 10*time.Millisecond)
+	})
+
+	// Run 이 처음 파일 상태를 기록한 뒤에 바꾼다.
+	time.Sleep(50 * time.Millisecond)
+	write("LOG_LEVEL: debug\n")
+	assert.Eventually(t, func() bool {
+		return rl.level.Level() == slog.LevelDebug
+	}, time.Second, 10*time.Millisecond)
+
+	// 잘못된 값으로 바뀌면 기존 값을 유지한다.
+	write("LOG_LEVEL: verbose\n")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, slog.LevelDebug, rl.level.Level())
+
+	cancel()
+	if err := eg.Wait(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
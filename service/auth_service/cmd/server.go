@@ -1,30 +1,79 @@
 package main
 
 import (
+	"auth_service/config"
 	"context"
+	"crypto/tls"
 	"errors"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 )
 
+const defaultShutdownTimeout = 10 * time.Second
+
 type Server struct {
-	server   *http.Server
-	listener net.Listener
+	server          *http.Server
+	listener        net.Listener
+	health          *Health
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+}
+
+type ServerOption func(*Server)
+
+// WithShutdownTimeout 진행 중인 요청이 끝나기를 기다리는 최대 시간
+func WithShutdownTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
 }
 
-func NewServer(l net.Listener, mux *http.ServeMux) *Server {
-	return &Server{
-		server:   &http.Server{Handler: mux},
-		listener: l,
+// WithLimits 헤더와 본문을 읽고 응답을 쓰는 시간, 유휴 연결 유지 시간과 헤더 크기를 제한한다.
+func WithLimits(cfg *config.Config) ServerOption {
+	return func(s *Server) {
+		s.server.ReadHeaderTimeout = cfg.ReadHeaderTimeout
+		s.server.ReadTimeout = cfg.ReadTimeout
+		s.server.WriteTimeout = cfg.WriteTimeout
+		s.server.IdleTimeout = cfg.IdleTimeout
+		s.server.MaxHeaderBytes = cfg.MaxHeaderBytes
 	}
 }
 
+// WithTLS cfg 로 TLS 를 적용해 서비스한다. 인증서는 cfg.GetCertificate 로 제공해야 한다.
+func WithTLS(cfg *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.server.TLSConfig = cfg
+	}
+}
+
+// WithDrain 종료 시 health 를 not ready 로 바꾸고 delay 만큼 기다린 뒤 Shutdown 한다.
+// 그동안 로드 밸런서가 readiness 실패를 보고 트래픽을 뺄 수 있다.
+func WithDrain(health *Health, delay time.Duration) ServerOption {
+	return func(s *Server) {
+		s.health = health
+		s.drainDelay = delay
+	}
+}
+
+func NewServer(l net.Listener, handler http.Handler, opts ...ServerOption) *Server {
+	s := &Server{
+		server:          &http.Server{Handler: handler},
+		listener:        l,
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 func (s *Server) Run(ctx context.Context) error {
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -32,8 +81,8 @@ func (s *Server) Run(ctx context.Context) error {
 	// 반환 값으로 오류를 받을 수 없어서 errgroup 패키지를 사용하여 오류를 반환
 	eg, ctx := errgroup.WithContext(ctx)
 	eg.Go(func() error {
-		if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("failed to close: %+v", err)
+		if err := s.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("failed to close", "error", err)
 			return err
 		}
 		return nil
@@ -41,9 +90,25 @@ func (s *Server) Run(ctx context.Context) error {
 
 	// 컨텍스트 취소 시 서버 종료
 	<-ctx.Done()
-	if err := s.server.Shutdown(context.Background()); err != nil {
-		log.Printf("failed to shutdown: %+v", err)
+	if s.health != nil {
+		s.health.Drain()
+		time.Sleep(s.drainDelay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(shutdownCtx); err != nil {
+		// 시간 안에 끝나지 않은 연결은 강제로 닫는다.
+		slog.Error("failed to shutdown", "error", err)
+		_ = s.server.Close()
+		return errors.Join(err, eg.Wait())
 	}
 
 	return eg.Wait()
 }
+
+func (s *Server) serve() error {
+	if s.server.TLSConfig != nil {
+		return s.server.ServeTLS(s.listener, "", "")
+	}
+	return s.server.Serve(s.listener)
+}
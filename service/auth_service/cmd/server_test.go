@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"pkg/middleware"
+	"pkg/tlsconfig"
+	"pkg/tlsconfig/tlstest"
 	"testing"
 	"time"
 
@@ -50,3 +54,100 @@ func TestRun(t *testing.T) {
 		t.Errorf("expected nil, got %v", err)
 	}
 }
+
+func TestRun_Drain(t *testing.T) {
+	assertions := assert.New(t)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen port: %v", err)
+	}
+
+	health := NewHealth(time.Second)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", health.ReadyzHandler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		s := NewServer(listener, mux, WithDrain(health, 500*time.Millisecond))
+		return s.Run(ctx)
+	})
+
+	url := fmt.Sprintf("http://%s/readyz", listener.Addr())
+	res, err := http.Get(url)
+	assertions.NoError(err)
+	_ = res.Body.Close()
+	assertions.Equal(http.StatusOK, res.StatusCode)
+
+	// 종료가 시작된 뒤에도 drain 동안은 요청을 받고 not ready 를 반환한다.
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	res, err = http.Get(url)
+	assertions.NoError(err)
+	_ = res.Body.Close()
+	assertions.Equal(http.StatusServiceUnavailable, res.StatusCode)
+
+	if err := eg.Wait(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestRun_MutualTLS(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	certPEM, keyPEM := ca.Server(t)
+	certFile, keyFile := tlstest.WriteFiles(t, t.TempDir(), certPEM, keyPEM)
+	reloader, err := tlsconfig.NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to load certificate: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen port: %v", err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", ok)
+	mux.HandleFunc("/metrics", middleware.Chain(ok, RequireClientCert()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		s := NewServer(listener, mux, WithTLS(tlsconfig.Server(reloader, ca.Pool())))
+		return s.Run(ctx)
+	})
+
+	tests := map[string]struct {
+		path     string
+		certs    []tls.Certificate
+		expected int
+	}{
+		"public route":                  {path: "/login", expected: http.StatusOK},
+		"internal route without client": {path: "/metrics", expected: http.StatusForbidden},
+		"internal route with client":    {path: "/metrics", certs: []tls.Certificate{ca.Client(t, "internal")}, expected: http.StatusOK},
+		"public route with client":      {path: "/login", certs: []tls.Certificate{ca.Client(t, "internal")}, expected: http.StatusOK},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      ca.Pool(),
+				Certificates: tt.certs,
+			}}}
+			res, err := client.Get(fmt.Sprintf("https://%s%s", listener.Addr(), tt.path))
+			if err != nil {
+				t.Fatalf("failed to request: %v", err)
+			}
+			_ = res.Body.Close()
+			assert.Equal(t, tt.expected, res.StatusCode)
+		})
+	}
+
+	cancel()
+	if err := eg.Wait(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
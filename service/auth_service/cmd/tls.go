@@ -0,0 +1,29 @@
+package main
+
+import (
+	"auth_service/config"
+	"crypto/tls"
+	"crypto/x509"
+	"pkg/tlsconfig"
+)
+
+// newTLSConfig TLS 설정이 없으면 nil 을 반환한다. 설정 조합은 config.Validate 에서 확인한다.
+func newTLSConfig(cfg *config.Config) (*tls.Config, *tlsconfig.Reloader, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil, nil
+	}
+
+	reloader, err := tlsconfig.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var clientCAs *x509.CertPool
+	if cfg.TLSClientCAFile != "" {
+		if clientCAs, err = tlsconfig.LoadCertPool(cfg.TLSClientCAFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return tlsconfig.Server(reloader, clientCAs), reloader, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"auth_service/internal/model"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runUser 첫 관리자를 만들거나 계정을 관리한다.
+func runUser(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "create":
+		email := fs.String("email", "", "email")
+		password := fs.String("password", "", "password. read from stdin when empty")
+		role := fs.String("role", model.RoleAdmin, "admin or user")
+		if err := parseNoArgs(fs, args[1:]); err != nil {
+			return err
+		}
+		if *email == "" {
+			return fmt.Errorf("-email is required\n%w", errUsage)
+		}
+		// 셸 기록에 남지 않도록 비밀번호는 표준 입력으로 받을 수 있다.
+		if *password == "" {
+			p, err := readLine(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			*password = p
+		}
+		if *password == "" {
+			return errors.New("password is required")
+		}
+
+		return withApp(ctx, func(a *app) error {
+			user, err := a.service.CreateUser(ctx, *email, *password, *role)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "created user %d (%s, %s)\n", user.ID, user.Email, user.Role)
+			return err
+		})
+	case "list":
+		after := fs.Int64("after", 0, "list users with id greater than this")
+		limit := fs.Int("limit", 100, "maximum number of users")
+		if err := parseNoArgs(fs, args[1:]); err != nil {
+			return err
+		}
+		if *limit < 1 {
+			return fmt.Errorf("-limit must be positive: %d", *limit)
+		}
+
+		return withApp(ctx, func(a *app) error {
+			users, err := a.service.ListUsers(ctx, *after, *limit)
+			if err != nil {
+				return err
+			}
+			return printUsers(w, users)
+		})
+	case "set-role":
+		id := fs.Int64("id", 0, "user id")
+		role := fs.String("role", "", "admin or user")
+		if err := parseNoArgs(fs, args[1:]); err != nil {
+			return err
+		}
+		if *id == 0 || *role == "" {
+			return fmt.Errorf("-id and -role are required\n%w", errUsage)
+		}
+
+		return withApp(ctx, func(a *app) error {
+			if err := a.service.SetRole(ctx, *id, *role); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(w, "set role of user %d to %s\n", *id, *role)
+			return err
+		})
+	case "disable":
+		id := fs.Int64("id", 0, "user id")
+		if err := parseNoArgs(fs, args[1:]); err != nil {
+			return err
+		}
+		if *id == 0 {
+			return fmt.Errorf("-id is required\n%w", errUsage)
+		}
+
+		return withApp(ctx, func(a *app) error {
+			if err := a.service.DisableUser(ctx, *id); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(w, "disabled user %d\n", *id)
+			return err
+		})
+	default:
+		return fmt.Errorf("unknown user command %q\n%w", args[0], errUsage)
+	}
+}
+
+func printUsers(w io.Writer, users []model.User) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tEMAIL\tROLE\tDEVICE\tCREATED\tLAST LOGIN\tDISABLED")
+	for _, u := range users {
+		disabled := "-"
+		if u.DisabledAt != nil {
+			disabled = u.DisabledAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			u.ID, orDash(u.Email), u.Role, orDash(u.DeviceID),
+			u.CreatedAt.Format(time.RFC3339), u.LastLogin.Format(time.RFC3339), disabled)
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// parseNoArgs 위치 인자를 받지 않는 명령의 플래그를 읽는다.
+func parseNoArgs(fs *flag.FlagSet, args []string) error {
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("%s: unexpected arguments %v\n%w", fs.Name(), rest, errUsage)
+	}
+	return nil
+}
+
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -1,27 +1,315 @@
 package config
 
 import (
+	"auth_service/internal/password"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"pkg/tracing"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/caarlos0/env/v11"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const redacted = "[REDACTED]"
+
+// Config 환경 변수에서 읽는다. CONFIG_FILE 로 YAML, TOML 파일을 지정하면 파일 값 위에 환경 변수를 덮어쓴다.
+// 값이 없으면 ENV 에 맞는 profile 기본값, 그 다음 envDefault 를 사용한다.
+// secret, file 태그가 붙은 값은 <NAME>_FILE 로 파일에서 읽을 수 있고 secret 값은 출력할 때 가려진다.
 type Config struct {
+	// dev, test, prod
 	Env  string `env:"ENV" envDefault:"dev"`
-	Port int    `env:"PORT" envDefault:"80"`
+	Port int    `env:"PORT" envDefault:"8080"`
+
+	// 설정 파일이 바뀌거나 SIGHUP 을 받으면 로그 레벨, 요청 제한, CORS origin, JWT 키를 다시 읽는다.
+	File                 string        `env:"CONFIG_FILE"`
+	ConfigReloadInterval time.Duration `env:"CONFIG_RELOAD_INTERVAL" envDefault:"10s"`
+
+	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
+	// none, stdout. stdout 은 OpenTelemetry 의 exporter 이름이고 로그와 섞이지 않도록 표준 에러에 쓴다.
+	TraceExporter string `env:"TRACE_EXPORTER" envDefault:"none"`
+
+	DbUser string `env:"DB_USER"`
+	DbPw   string `env:"DB_PASSWORD" secret:"true"`
+	DbHost string `env:"DB_HOST"`
+	DbPort string `env:"DB_PORT"`
+	DbName string `env:"DB_NAME"`
+	// 연결 풀과 연결 옵션. 0 인 수명과 타임아웃은 제한하지 않는다.
+	DbMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" envDefault:"10"`
+	DbMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" envDefault:"10"`
+	DbConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"3m"`
+	DbConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"0s"`
+	DbDialTimeout     time.Duration `env:"DB_DIAL_TIMEOUT" envDefault:"3s"`
+	DbReadTimeout     time.Duration `env:"DB_READ_TIMEOUT" envDefault:"0s"`
+	DbWriteTimeout    time.Duration `env:"DB_WRITE_TIMEOUT" envDefault:"0s"`
+	// true, skip-verify, preferred. 비어 있으면 TLS 를 쓰지 않는다.
+	DbTLS       string `env:"DB_TLS"`
+	DbCharset   string `env:"DB_CHARSET" envDefault:"utf8mb4"`
+	DbCollation string `env:"DB_COLLATION"`
+	DbLocation  string `env:"DB_LOCATION" envDefault:"UTC"`
+	// DB 컨테이너가 늦게 뜨는 경우를 위해 시작할 때 이 시간 동안 연결을 재시도한다.
+	DbConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT" envDefault:"30s"`
+	// 로그인 조회를 보낼 복제본 host:port 목록. 계정 정보는 DB_USER, DB_PASSWORD, DB_NAME 을 함께 쓴다.
+	DbReplicas             []string      `env:"DB_REPLICAS" envSeparator:","`
+	DbReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" envDefault:"5s"`
+	// 요청이 쓰기를 한 뒤에는 같은 요청의 조회를 primary 로 보낸다.
+	DbReadYourWrites bool `env:"DB_READ_YOUR_WRITES" envDefault:"true"`
+	// 시작할 때 남은 마이그레이션을 적용한다. 끄면 migrate 명령으로 따로 적용한다.
+	DbMigrate bool `env:"DB_MIGRATE" envDefault:"false"`
 
-	DbUser    string `env:"DB_USER"`
-	DbPw      string `env:"DB_PASSWORD"`
-	DbHost    string `env:"DB_HOST"`
-	DbPort    string `env:"DB_PORT"`
-	DbName    string `env:"DB_NAME"`
 	RedisHost string `env:"REDIS_HOST"`
 	RedisPort string `env:"REDIS_PORT"`
-	RedisPw   string `env:"REDIS_PASSWORD"`
+	RedisPw   string `env:"REDIS_PASSWORD" secret:"true"`
+
+	JWTIssuer     string        `env:"JWT_ISSUER" envDefault:"auth_service"`
+	JWTExpiresIn  time.Duration `env:"JWT_EXPIRES_IN" envDefault:"24h"`
+	JWTSignMethod string        `env:"JWT_SIGN_METHOD" envDefault:"RS256"`
+	// PEM 형식. 둘 다 비어 있으면 개발용으로 내장된 키를 사용한다.
+	JWTPrivateKey string `env:"JWT_PRIVATE_KEY" secret:"true"`
+	JWTPublicKey  string `env:"JWT_PUBLIC_KEY" file:"true"`
+	// Redis 에 저장한 세션의 유지 시간
+	SessionExpire time.Duration `env:"SESSION_EXPIRE" envDefault:"24h"`
+
+	// 클라이언트 IP 마다 초당 허용하는 인증 요청 수. 0 이면 제한하지 않는다.
+	RateLimitRPS   float64 `env:"RATE_LIMIT_RPS" envDefault:"5"`
+	RateLimitBurst int     `env:"RATE_LIMIT_BURST" envDefault:"10"`
+
+	PasswordAlgorithm string `env:"PASSWORD_ALGORITHM" envDefault:"bcrypt"`
+	BcryptCost        int    `env:"BCRYPT_COST" envDefault:"10"`
+	Argon2Time        uint32 `env:"ARGON2_TIME" envDefault:"1"`
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Threads     uint8  `env:"ARGON2_THREADS" envDefault:"4"`
+
+	// 이미 가입된 이메일에도 같은 응답을 주고 결과는 메일로 알린다.
+	ConcealRegistration bool `env:"CONCEAL_REGISTRATION" envDefault:"false"`
+
+	ReadHeaderTimeout time.Duration `env:"READ_HEADER_TIMEOUT" envDefault:"5s"`
+	ReadTimeout       time.Duration `env:"READ_TIMEOUT" envDefault:"10s"`
+	WriteTimeout      time.Duration `env:"WRITE_TIMEOUT" envDefault:"10s"`
+	IdleTimeout       time.Duration `env:"IDLE_TIMEOUT" envDefault:"60s"`
+	MaxHeaderBytes    int           `env:"MAX_HEADER_BYTES" envDefault:"16384"`
+	MaxBodyBytes      int64         `env:"MAX_BODY_BYTES" envDefault:"65536"`
+
+	// 인증서와 키를 모두 설정하면 TLS 로 서비스한다. 파일이 바뀌면 다시 읽는다.
+	TLSCertFile       string        `env:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `env:"TLS_KEY_FILE"`
+	TLSReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" envDefault:"1m"`
+	// 설정하면 내부 라우트는 이 CA 가 발급한 클라이언트 인증서를 요구한다.
+	TLSClientCAFile string `env:"TLS_CLIENT_CA_FILE"`
+
+	// 비어 있으면 CORS 헤더를 보내지 않는다. https://*.example.com 처럼 와일드카드를 쓸 수 있다.
+	CORSAllowedOrigins   []string      `env:"CORS_ALLOWED_ORIGINS" envSeparator:","`
+	CORSAllowedMethods   []string      `env:"CORS_ALLOWED_METHODS" envSeparator:"," envDefault:"GET,POST"`
+	CORSAllowedHeaders   []string      `env:"CORS_ALLOWED_HEADERS" envSeparator:"," envDefault:"Content-Type,Authorization,X-Request-ID"`
+	CORSExposedHeaders   []string      `env:"CORS_EXPOSED_HEADERS" envSeparator:"," envDefault:"X-Request-ID"`
+	CORSAllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" envDefault:"false"`
+	CORSMaxAge           time.Duration `env:"CORS_MAX_AGE" envDefault:"10m"`
+
+	// 준비 상태 확인 시 의존성마다 기다리는 시간
+	ReadinessTimeout time.Duration `env:"READINESS_TIMEOUT" envDefault:"1s"`
+	// 종료 신호를 받은 뒤 로드 밸런서가 트래픽을 빼도록 not ready 로 기다리는 시간
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5s"`
+	// 진행 중인 요청과 리소스 정리를 각각 기다리는 최대 시간
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"10s"`
+
+	GuestTTL             time.Duration `env:"GUEST_TTL" envDefault:"720h"`
+	GuestCleanupInterval time.Duration `env:"GUEST_CLEANUP_INTERVAL" envDefault:"1h"`
+
+	// 커밋된 세션 변경과 이벤트를 outbox 에서 찾는 주기와 한 번에 처리하는 개수
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
 }
 
 func New() (*Config, error) {
+	environ, err := environment()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{}
-	if err := env.Parse(cfg); err != nil {
+	if err = env.ParseWithOptions(cfg, env.Options{Environment: environ}); err != nil {
+		return nil, err
+	}
+	if err = cfg.Validate(); err != nil {
 		return nil, err
 	}
 	return cfg, nil
 }
+
+// Validate 잘못된 값을 모두 모아서 반환한다.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if _, ok := profiles[c.Env]; !ok {
+		errs = append(errs, fmt.Errorf("ENV must be one of dev, test, prod: %q", c.Env))
+	}
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"DB_USER", c.DbUser},
+		{"DB_HOST", c.DbHost},
+		{"DB_PORT", c.DbPort},
+		{"DB_NAME", c.DbName},
+		{"REDIS_HOST", c.RedisHost},
+		{"REDIS_PORT", c.RedisPort},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", r.name))
+		}
+	}
+
+	if c.DbMaxOpenConns < 1 || c.DbMaxIdleConns < 0 || c.DbMaxIdleConns > c.DbMaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_OPEN_CONNS must be positive and DB_MAX_IDLE_CONNS between 0 and DB_MAX_OPEN_CONNS: %d, %d",
+			c.DbMaxOpenConns, c.DbMaxIdleConns))
+	}
+	if _, err := time.LoadLocation(c.DbLocation); err != nil {
+		errs = append(errs, fmt.Errorf("DB_LOCATION is invalid: %w", err))
+	}
+	for _, addr := range c.DbReplicas {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Errorf("DB_REPLICAS must be host:port: %w", err))
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535: %d", c.Port))
+	}
+
+	// 0 이하의 주기는 time.NewTicker 가 panic 을 일으킨다.
+	positive := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"JWT_EXPIRES_IN", c.JWTExpiresIn},
+		{"SESSION_EXPIRE", c.SessionExpire},
+		{"READINESS_TIMEOUT", c.ReadinessTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"DB_CONNECT_TIMEOUT", c.DbConnectTimeout},
+		{"DB_REPLICA_CHECK_INTERVAL", c.DbReplicaCheckInterval},
+		{"TLS_RELOAD_INTERVAL", c.TLSReloadInterval},
+		{"CONFIG_RELOAD_INTERVAL", c.ConfigReloadInterval},
+		{"GUEST_TTL", c.GuestTTL},
+		{"GUEST_CLEANUP_INTERVAL", c.GuestCleanupInterval},
+		{"OUTBOX_POLL_INTERVAL", c.OutboxPollInterval},
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive: %s", p.name, p.value))
+		}
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+		errs = append(errs, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE"))
+	}
+	if (c.JWTPrivateKey == "") != (c.JWTPublicKey == "") {
+		errs = append(errs, errors.New("JWT_PRIVATE_KEY and JWT_PUBLIC_KEY must be set together"))
+	}
+	if c.OutboxBatchSize < 1 {
+		errs = append(errs, fmt.Errorf("OUTBOX_BATCH_SIZE must be positive: %d", c.OutboxBatchSize))
+	}
+	if c.RateLimitRPS < 0 || (c.RateLimitRPS > 0 && c.RateLimitBurst < 1) {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_RPS must not be negative and RATE_LIMIT_BURST must be positive: %v, %d",
+			c.RateLimitRPS, c.RateLimitBurst))
+	}
+
+	errs = append(errs, c.validateNames()...)
+	switch c.PasswordAlgorithm {
+	case password.Bcrypt:
+		if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+			errs = append(errs, fmt.Errorf("BCRYPT_COST must be between %d and %d: %d", bcrypt.MinCost, bcrypt.MaxCost, c.BcryptCost))
+		}
+	case password.Argon2id:
+		// argon2 는 스레드마다 최소 8 KiB 의 메모리를 사용한다.
+		if c.Argon2Time < 1 || c.Argon2Threads < 1 || c.Argon2Memory < 8*uint32(c.Argon2Threads) {
+			errs = append(errs, fmt.Errorf("ARGON2_TIME and ARGON2_THREADS must be positive and ARGON2_MEMORY at least 8*ARGON2_THREADS: t=%d m=%d p=%d",
+				c.Argon2Time, c.Argon2Memory, c.Argon2Threads))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("PASSWORD_ALGORITHM must be one of %s, %s: %q", password.Bcrypt, password.Argon2id, c.PasswordAlgorithm))
+	}
+
+	if c.Env == "prod" {
+		errs = append(errs, c.validateProd()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// jwtSignMethods 키 쌍을 만들고 읽을 수 있는 비대칭 서명 방식
+var jwtSignMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// validateNames 시작한 뒤에야 실패하지 않도록 이름으로 고르는 값을 확인한다.
+func (c *Config) validateNames() []error {
+	var errs []error
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error: %q", c.LogLevel))
+	}
+	if !slices.Contains(jwtSignMethods, c.JWTSignMethod) {
+		errs = append(errs, fmt.Errorf("JWT_SIGN_METHOD must be one of %s: %q", strings.Join(jwtSignMethods, ", "), c.JWTSignMethod))
+	}
+	if c.TraceExporter != tracing.ExporterNone && c.TraceExporter != tracing.ExporterStdout {
+		errs = append(errs, fmt.Errorf("TRACE_EXPORTER must be one of %s, %s: %q", tracing.ExporterNone, tracing.ExporterStdout, c.TraceExporter))
+	}
+	return errs
+}
+
+// validateProd 개발용 기본값으로 운영 환경이 시작되지 않게 한다.
+func (c *Config) validateProd() []error {
+	var errs []error
+	if c.JWTPrivateKey == "" {
+		errs = append(errs, errors.New("prod requires JWT_PRIVATE_KEY and JWT_PUBLIC_KEY instead of the embedded keys"))
+	}
+	if c.DbPw == "" {
+		errs = append(errs, errors.New("prod requires DB_PASSWORD"))
+	}
+	if c.RedisPw == "" {
+		errs = append(errs, errors.New("prod requires REDIS_PASSWORD"))
+	}
+	if slices.Contains(c.CORSAllowedOrigins, "*") && c.CORSAllowCredentials {
+		errs = append(errs, errors.New("prod does not allow CORS_ALLOWED_ORIGINS=* with CORS_ALLOW_CREDENTIALS"))
+	}
+	if c.RateLimitRPS == 0 {
+		errs = append(errs, errors.New("prod requires RATE_LIMIT_RPS"))
+	}
+	return errs
+}
+
+// LogValue 환경 변수 이름으로 값을 나열하고 secret 값은 가린다.
+func (c Config) LogValue() slog.Value {
+	v := reflect.ValueOf(c)
+	t := v.Type()
+
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := range t.NumField() {
+		name, secret, _ := fieldParams(t.Field(i))
+		value := v.Field(i).Interface()
+		if secret && !v.Field(i).IsZero() {
+			value = redacted
+		}
+		attrs = append(attrs, slog.Any(name, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (c Config) String() string {
+	return c.LogValue().String()
+}
+
+// fieldParams 필드의 환경 변수 이름과 secret 여부, <NAME>_FILE 로 읽을 수 있는지를 반환한다.
+func fieldParams(f reflect.StructField) (name string, secret, file bool) {
+	name, _, _ = strings.Cut(f.Tag.Get("env"), ",")
+	secret = f.Tag.Get("secret") == "true"
+	return name, secret, secret || f.Tag.Get("file") == "true"
+}
@@ -2,11 +2,38 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	for k, v := range map[string]string{
+		"DB_USER":    "auth",
+		"DB_HOST":    "localhost",
+		"DB_PORT":    "3306",
+		"DB_NAME":    "auth",
+		"REDIS_HOST": "localhost",
+		"REDIS_PORT": "6379",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("cannot write %s: %v", name, err)
+	}
+	return path
+}
+
 func TestNew(t *testing.T) {
-	port := 8080
+	setRequiredEnv(t)
+	port := 8081
 	t.Setenv("PORT", fmt.Sprint(port))
 
 	cfg, err := New()
@@ -21,3 +48,301 @@ func TestNew(t *testing.T) {
 		t.Errorf("expected env %s, got %s", env, cfg.Env)
 	}
 }
+
+func TestNew_CORS(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://game.example.com,https://*.preview.example.com")
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("cannot create config: %v", err)
+	}
+	if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSAllowedOrigins[1] != "https://*.preview.example.com" {
+		t.Errorf("unexpected allowed origins %v", cfg.CORSAllowedOrigins)
+	}
+	if len(cfg.CORSAllowedMethods) != 2 {
+		t.Errorf("expected default methods, got %v", cfg.CORSAllowedMethods)
+	}
+}
+
+func TestNew_Validate(t *testing.T) {
+	t.Setenv("DB_HOST", "")
+	t.Setenv("REDIS_HOST", "")
+	t.Setenv("PORT", "0")
+	t.Setenv("GUEST_CLEANUP_INTERVAL", "0s")
+	t.Setenv("TLS_KEY_FILE", "tls.key")
+	t.Setenv("DB_MAX_IDLE_CONNS", "20")
+	t.Setenv("DB_LOCATION", "Mars/Base")
+	t.Setenv("DB_REPLICAS", "replica-1:3306,replica-2")
+	t.Setenv("RATE_LIMIT_BURST", "0")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("JWT_SIGN_METHOD", "HS256")
+	t.Setenv("TRACE_EXPORTER", "jaeger")
+	t.Setenv("BCRYPT_COST", "32")
+
+	_, err := New()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, expected := range []string{
+		"DB_HOST is required",
+		"REDIS_HOST is required",
+		"PORT must be between 1 and 65535",
+		"GUEST_CLEANUP_INTERVAL must be positive",
+		"TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+		"DB_MAX_IDLE_CONNS between 0 and DB_MAX_OPEN_CONNS",
+		"DB_LOCATION is invalid",
+		"DB_REPLICAS must be host:port",
+		"RATE_LIMIT_BURST must be positive",
+		"LOG_LEVEL must be one of",
+		"JWT_SIGN_METHOD must be one of",
+		"TRACE_EXPORTER must be one of",
+		"BCRYPT_COST must be between 4 and 31",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+}
+
+func TestNew_ValidatePassword(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected string
+	}{
+		"unknown algorithm": {
+			env:      map[string]string{"PASSWORD_ALGORITHM": "md5"},
+			expected: "PASSWORD_ALGORITHM must be one of bcrypt, argon2id",
+		},
+		"bcrypt cost": {
+			env:      map[string]string{"BCRYPT_COST": "3"},
+			expected: "BCRYPT_COST must be between 4 and 31",
+		},
+		"argon2 time": {
+			env:      map[string]string{"PASSWORD_ALGORITHM": "argon2id", "ARGON2_TIME": "0"},
+			expected: "ARGON2_TIME and ARGON2_THREADS must be positive",
+		},
+		"argon2 memory": {
+			env:      map[string]string{"PASSWORD_ALGORITHM": "argon2id", "ARGON2_MEMORY": "16", "ARGON2_THREADS": "4"},
+			expected: "ARGON2_MEMORY at least 8*ARGON2_THREADS",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := New()
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected %q in %v", tt.expected, err)
+			}
+		})
+	}
+
+	setRequiredEnv(t)
+	t.Setenv("PASSWORD_ALGORITHM", "argon2id")
+	// argon2id 를 쓰면 bcrypt cost 는 확인하지 않는다.
+	t.Setenv("BCRYPT_COST", "0")
+	if _, err := New(); err != nil {
+		t.Errorf("expected valid argon2id config, got %v", err)
+	}
+}
+
+func TestNew_File(t *testing.T) {
+	tests := map[string]string{
+		"config.yaml": `
+DB_USER: auth
+db_host: file-db
+DB_PORT: 3306
+DB_NAME: auth
+REDIS_HOST: file-redis
+REDIS_PORT: 6379
+PORT: 9000
+CONCEAL_REGISTRATION: true
+CORS_ALLOWED_ORIGINS:
+  - https://game.example.com
+  - https://admin.example.com
+`,
+		"config.toml": `
+DB_USER = "auth"
+db_host = "file-db"
+DB_PORT = 3306
+DB_NAME = "auth"
+REDIS_HOST = "file-redis"
+REDIS_PORT = 6379
+PORT = 9000
+CONCEAL_REGISTRATION = true
+CORS_ALLOWED_ORIGINS = ["https://game.example.com", "https://admin.example.com"]
+`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", writeFile(t, name, content))
+			// 환경 변수가 파일보다 우선한다.
+			t.Setenv("REDIS_HOST", "env-redis")
+
+			cfg, err := New()
+			if err != nil {
+				t.Fatalf("cannot create config: %v", err)
+			}
+			if cfg.DbHost != "file-db" {
+				t.Errorf("expected db host from file, got %q", cfg.DbHost)
+			}
+			if cfg.RedisHost != "env-redis" {
+				t.Errorf("expected redis host from env, got %q", cfg.RedisHost)
+			}
+			if cfg.Port != 9000 || !cfg.ConcealRegistration {
+				t.Errorf("unexpected port %d, conceal %v", cfg.Port, cfg.ConcealRegistration)
+			}
+			if len(cfg.CORSAllowedOrigins) != 2 {
+				t.Errorf("unexpected allowed origins %v", cfg.CORSAllowedOrigins)
+			}
+		})
+	}
+}
+
+func TestNew_FileErrors(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		content string
+	}{
+		"unsupported type": {name: "config.json", content: `{}`},
+		"nested value":     {name: "config.yaml", content: "db:\n  host: file-db\n"},
+		"malformed":        {name: "config.toml", content: `DB_HOST = `},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("CONFIG_FILE", writeFile(t, tt.name, tt.content))
+
+			if _, err := New(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestNew_SecretFile(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret\n"))
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("cannot create config: %v", err)
+	}
+	if cfg.DbPw != "s3cret" {
+		t.Errorf("expected password from file, got %q", cfg.DbPw)
+	}
+
+	t.Setenv("DB_PASSWORD", "other")
+	if _, err = New(); err == nil {
+		t.Error("expected error when both DB_PASSWORD and DB_PASSWORD_FILE are set")
+	}
+}
+
+func TestConfig_String(t *testing.T) {
+	cfg := Config{DbHost: "localhost", DbPw: "s3cret", RedisPw: ""}
+
+	for _, s := range []string{cfg.String(), fmt.Sprint(&cfg), fmt.Sprintf("%+v", cfg)} {
+		if strings.Contains(s, "s3cret") {
+			t.Errorf("expected password to be redacted: %s", s)
+		}
+		if !strings.Contains(s, "DB_PASSWORD="+redacted) || !strings.Contains(s, "DB_HOST=localhost") {
+			t.Errorf("unexpected output: %s", s)
+		}
+	}
+}
+
+func TestNew_Profile(t *testing.T) {
+	tests := map[string]struct {
+		env        string
+		override   string
+		logLevel   string
+		bcryptCost int
+		rateLimit  float64
+	}{
+		"dev":      {env: "dev", logLevel: "debug", bcryptCost: 10, rateLimit: 5},
+		"default":  {env: "", logLevel: "debug", bcryptCost: 10, rateLimit: 5},
+		"test":     {env: "test", logLevel: "warn", bcryptCost: 4, rateLimit: 0},
+		"override": {env: "test", override: "error", logLevel: "error", bcryptCost: 4, rateLimit: 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("ENV", tt.env)
+			if tt.override != "" {
+				t.Setenv("LOG_LEVEL", tt.override)
+			}
+
+			cfg, err := New()
+			if err != nil {
+				t.Fatalf("cannot create config: %v", err)
+			}
+			if cfg.LogLevel != tt.logLevel || cfg.BcryptCost != tt.bcryptCost || cfg.RateLimitRPS != tt.rateLimit {
+				t.Errorf("unexpected profile values: log level %q, bcrypt cost %d, rate limit %v",
+					cfg.LogLevel, cfg.BcryptCost, cfg.RateLimitRPS)
+			}
+		})
+	}
+}
+
+func TestNew_ProfileFromFile(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", "ENV: test\n"))
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("cannot create config: %v", err)
+	}
+	if cfg.Env != "test" || cfg.BcryptCost != 4 {
+		t.Errorf("expected test profile, got env %q, bcrypt cost %d", cfg.Env, cfg.BcryptCost)
+	}
+}
+
+func TestNew_UnknownProfile(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("ENV", "staging")
+
+	_, err := New()
+	if err == nil || !strings.Contains(err.Error(), "ENV must be one of dev, test, prod") {
+		t.Errorf("expected unknown profile error, got %v", err)
+	}
+}
+
+func TestNew_Prod(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("ENV", "prod")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("RATE_LIMIT_RPS", "0")
+
+	_, err := New()
+	if err == nil {
+		t.Fatal("expected prod to refuse insecure defaults")
+	}
+	for _, expected := range []string{"JWT_PRIVATE_KEY", "DB_PASSWORD", "REDIS_PASSWORD", "CORS_ALLOWED_ORIGINS=*", "RATE_LIMIT_RPS"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+	t.Setenv("RATE_LIMIT_RPS", "5")
+	t.Setenv("DB_PASSWORD", "db-password")
+	t.Setenv("REDIS_PASSWORD", "redis-password")
+	t.Setenv("JWT_PRIVATE_KEY_FILE", writeFile(t, "private.pem", "private key"))
+	t.Setenv("JWT_PUBLIC_KEY_FILE", writeFile(t, "public.pem", "public key"))
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("cannot create config: %v", err)
+	}
+	if cfg.BcryptCost != 12 || cfg.JWTPublicKey != "public key" {
+		t.Errorf("unexpected prod values: bcrypt cost %d, public key %q", cfg.BcryptCost, cfg.JWTPublicKey)
+	}
+	if strings.Contains(cfg.String(), "private key") {
+		t.Errorf("expected JWT private key to be redacted: %s", cfg)
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+const defaultProfile = "dev"
+
+// profiles ENV 별 기본값. 설정 파일과 환경 변수가 이 값을 덮어쓴다.
+var profiles = map[string]map[string]string{
+	"dev": {
+		"LOG_LEVEL":            "debug",
+		"BCRYPT_COST":          "10",
+		"CORS_ALLOWED_ORIGINS": "http://localhost:*",
+		"SHUTDOWN_DRAIN_DELAY": "0s",
+		"DB_MIGRATE":           "true",
+	},
+	"test": {
+		"LOG_LEVEL":            "warn",
+		"BCRYPT_COST":          "4",
+		"RATE_LIMIT_RPS":       "0",
+		"SHUTDOWN_DRAIN_DELAY": "0s",
+	},
+	"prod": {
+		"LOG_LEVEL":            "info",
+		"BCRYPT_COST":          "12",
+		"SHUTDOWN_DRAIN_DELAY": "5s",
+	},
+}
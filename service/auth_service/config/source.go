@@ -0,0 +1,106 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v11"
+	"gopkg.in/yaml.v3"
+)
+
+const configFileEnv = "CONFIG_FILE"
+
+// environment profile, 설정 파일, 환경 변수, secret 파일 순으로 값을 합친다.
+func environment() (map[string]string, error) {
+	environ := env.ToMap(os.Environ())
+
+	var file map[string]string
+	if path := environ[configFileEnv]; path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file = values
+	}
+
+	name := cmp.Or(environ["ENV"], file["ENV"], defaultProfile)
+	merged := maps.Clone(profiles[name])
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, file)
+	maps.Copy(merged, environ)
+
+	if err := readSecretFiles(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// readFile 환경 변수 이름을 키로 쓰는 평평한 YAML, TOML 파일을 읽는다. 목록은 쉼표로 이어 붙인다.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file type: %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case map[string]any:
+			return nil, fmt.Errorf("nested config values are not supported: %s", k)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[strings.ToUpper(k)] = strings.Join(items, ",")
+		case nil:
+			values[strings.ToUpper(k)] = ""
+		default:
+			values[strings.ToUpper(k)] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// readSecretFiles 값이 비어 있고 <NAME>_FILE 이 설정되어 있으면 그 파일의 내용으로 채운다.
+func readSecretFiles(environ map[string]string) error {
+	t := reflect.TypeFor[Config]()
+	for i := range t.NumField() {
+		name, _, file := fieldParams(t.Field(i))
+		path := environ[name+"_FILE"]
+		if !file || path == "" {
+			continue
+		}
+		if environ[name] != "" {
+			return fmt.Errorf("both %s and %s_FILE are set", name, name)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %w", name, err)
+		}
+		environ[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return nil
+}
@@ -18,3 +18,32 @@ type loginResponse struct {
 	Message string `json:"message"`
 	Token   string `json:"token"`
 }
+
+type guestRegisterRequest struct {
+	DeviceID string `json:"device_id"`
+}
+
+type guestRegisterResponse struct {
+	Message string `json:"message"`
+	GuestID int64  `json:"guest_id"`
+	Secret  string `json:"secret"`
+	Token   string `json:"token"`
+}
+
+type guestLoginRequest struct {
+	GuestID  int64  `json:"guest_id"`
+	DeviceID string `json:"device_id"`
+	Secret   string `json:"secret"`
+}
+
+type guestUpgradeRequest struct {
+	GuestID  int64  `json:"guest_id"`
+	DeviceID string `json:"device_id"`
+	Secret   string `json:"secret"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type guestUpgradeResponse struct {
+	Message string `json:"message"`
+}
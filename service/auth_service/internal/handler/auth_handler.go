@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"auth_service/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"pkg/response"
 )
@@ -18,12 +20,16 @@ func NewAuthHandler(authService AuthService) *AuthHandler {
 
 func (h *AuthHandler) RegisterHandler(rw http.ResponseWriter, r *http.Request) {
 	req := &registerRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(rw, "invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, req); err != nil {
+		response.DecodeError(rw, err)
 		return
 	}
 
 	err := h.authService.RegisterUser(r.Context(), req.Email, req.Password)
+	if errors.Is(err, service.ErrUserExists) {
+		response.Error(rw, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		response.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
@@ -39,16 +45,21 @@ func (h *AuthHandler) RegisterHandler(rw http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) LoginHandler(rw http.ResponseWriter, r *http.Request) {
 	req := &loginRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.Error(rw, "invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, req); err != nil {
+		response.DecodeError(rw, err)
 		return
 	}
 
 	token, err := h.authService.LoginUser(r.Context(), req.Email, req.Password)
-	if err != nil {
+	if errors.Is(err, service.ErrInvalidCredential) {
 		response.Error(rw, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	if err != nil {
+		// DB 오류의 내용은 클라이언트에 알리지 않는다.
+		response.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
 
 	response.SetContentJSON(rw)
 	rw.WriteHeader(http.StatusOK)
@@ -58,3 +69,88 @@ func (h *AuthHandler) LoginHandler(rw http.ResponseWriter, r *http.Request) {
 	}
 	_ = json.NewEncoder(rw).Encode(res)
 }
+
+func (h *AuthHandler) RegisterGuestHandler(rw http.ResponseWriter, r *http.Request) {
+	req := &guestRegisterRequest{}
+	if err := decodeJSON(r, req); err != nil {
+		response.DecodeError(rw, err)
+		return
+	}
+	if req.DeviceID == "" {
+		response.Error(rw, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, secret, token, err := h.authService.RegisterGuest(r.Context(), req.DeviceID)
+	if err != nil {
+		response.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.SetContentJSON(rw)
+	rw.WriteHeader(http.StatusCreated)
+	res := &guestRegisterResponse{
+		Message: "success register guest",
+		GuestID: id,
+		Secret:  secret,
+		Token:   token,
+	}
+	_ = json.NewEncoder(rw).Encode(res)
+}
+
+func (h *AuthHandler) LoginGuestHandler(rw http.ResponseWriter, r *http.Request) {
+	req := &guestLoginRequest{}
+	if err := decodeJSON(r, req); err != nil {
+		response.DecodeError(rw, err)
+		return
+	}
+
+	token, err := h.authService.LoginGuest(r.Context(), req.GuestID, req.DeviceID, req.Secret)
+	if err != nil {
+		response.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response.SetContentJSON(rw)
+	rw.WriteHeader(http.StatusOK)
+	res := &loginResponse{
+		Message: "success login guest",
+		Token:   token,
+	}
+	_ = json.NewEncoder(rw).Encode(res)
+}
+
+func (h *AuthHandler) UpgradeGuestHandler(rw http.ResponseWriter, r *http.Request) {
+	req := &guestUpgradeRequest{}
+	if err := decodeJSON(r, req); err != nil {
+		response.DecodeError(rw, err)
+		return
+	}
+	if req.Email == "" || req.Password == "" {
+		response.Error(rw, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.authService.UpgradeGuest(r.Context(), req.GuestID, req.DeviceID, req.Secret, req.Email, req.Password)
+	if errors.Is(err, service.ErrUserExists) {
+		response.Error(rw, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		response.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// 가입 여부를 숨기면 성공과 이미 가입된 이메일 모두 결과를 메일로 알리고 같은 응답을 준다.
+	status, message := http.StatusOK, "success upgrade guest"
+	if h.authService.ConcealsRegistration() {
+		status, message = http.StatusAccepted, "check your email to finish the upgrade"
+	}
+
+	response.SetContentJSON(rw)
+	rw.WriteHeader(status)
+	res := &guestUpgradeResponse{
+		Message: message,
+	}
+	_ = json.NewEncoder(rw).Encode(res)
+}
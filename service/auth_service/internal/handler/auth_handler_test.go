@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"auth_service/internal/service"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) RegisterUser(ctx context.Context, email, password string) error {
+	return m.Called(ctx, email, password).Error(0)
+}
+
+func (m *MockAuthService) LoginUser(ctx context.Context, email, password string) (string, error) {
+	args := m.Called(ctx, email, password)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) RegisterGuest(ctx context.Context, deviceID string) (int64, string, string, error) {
+	args := m.Called(ctx, deviceID)
+	return args.Get(0).(int64), args.String(1), args.String(2), args.Error(3)
+}
+
+func (m *MockAuthService) LoginGuest(ctx context.Context, id int64, deviceID, secret string) (string, error) {
+	args := m.Called(ctx, id, deviceID, secret)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) UpgradeGuest(ctx context.Context, id int64, deviceID, secret, email, password string) error {
+	return m.Called(ctx, id, deviceID, secret, email, password).Error(0)
+}
+
+func (m *MockAuthService) ConcealsRegistration() bool {
+	return m.Called().Bool(0)
+}
+
+func TestLoginHandler(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected int
+		message  string
+	}{
+		"success":            {expected: http.StatusOK, message: "success login user"},
+		"invalid credential": {err: service.ErrInvalidCredential, expected: http.StatusUnauthorized, message: service.ErrInvalidCredential.Error()},
+		// DB 오류는 인증 실패로 보이지 않고 내용도 숨긴다.
+		"db error": {err: fmt.Errorf("failed to get user: %w", sql.ErrConnDone), expected: http.StatusInternalServerError, message: "Internal Server Error"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockService := new(MockAuthService)
+			mockService.On("LoginUser", mock.Anything, "test@example.com", "password123").Return("", tt.err)
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email": "test@example.com", "password": "password123"}`))
+			NewAuthHandler(mockService).LoginHandler(res, req)
+
+			assert.Equal(t, tt.expected, res.Code)
+			assert.Contains(t, res.Body.String(), tt.message)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpgradeGuestHandler(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		conceal  bool
+		expected int
+		message  string
+	}{
+		"success":        {expected: http.StatusOK, message: "success upgrade guest"},
+		"existing email": {err: service.ErrUserExists, expected: http.StatusConflict, message: service.ErrUserExists.Error()},
+		// 가입 여부를 숨기면 성공과 이미 가입된 이메일을 구분할 수 없게 같은 응답을 준다.
+		"concealed": {conceal: true, expected: http.StatusAccepted, message: "check your email"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockService := new(MockAuthService)
+			mockService.On("UpgradeGuest", mock.Anything, int64(1), "device", "secret", "test@example.com", "password123").Return(tt.err)
+			mockService.On("ConcealsRegistration").Return(tt.conceal)
+
+			res := httptest.NewRecorder()
+			body := `{"guest_id": 1, "device_id": "device", "secret": "secret", "email": "test@example.com", "password": "password123"}`
+			NewAuthHandler(mockService).UpgradeGuestHandler(res, httptest.NewRequest("POST", "/register/upgrade", strings.NewReader(body)))
+
+			assert.Equal(t, tt.expected, res.Code)
+			assert.Contains(t, res.Body.String(), tt.message)
+		})
+	}
+}
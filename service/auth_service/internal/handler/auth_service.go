@@ -5,4 +5,8 @@ import "context"
 type AuthService interface {
 	RegisterUser(ctx context.Context, email, password string) error
 	LoginUser(ctx context.Context, email, password string) (string, error)
+	RegisterGuest(ctx context.Context, deviceID string) (id int64, secret, token string, err error)
+	LoginGuest(ctx context.Context, id int64, deviceID, secret string) (string, error)
+	UpgradeGuest(ctx context.Context, id int64, deviceID, secret, email, password string) error
+	ConcealsRegistration() bool
 }
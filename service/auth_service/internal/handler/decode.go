@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+var errTrailingData = errors.New("unexpected data after JSON body")
+
+// decodeJSON 요청 본문을 v 로 디코딩한다. 모르는 필드나 JSON 뒤에 남은 데이터가 있으면 실패한다.
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return err
+		}
+		return errTrailingData
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := map[string]struct {
+		body    string
+		wantErr bool
+	}{
+		"valid":         {body: `{"email": "test@example.com", "password": "password123"}`},
+		"unknown field": {body: `{"email": "test@example.com", "role": "admin"}`, wantErr: true},
+		"trailing data": {body: `{"email": "test@example.com"} {"email": "other@example.com"}`, wantErr: true},
+		"trailing junk": {body: `{"email": "test@example.com"} x`, wantErr: true},
+		"malformed":     {body: `{"email": `, wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/register", strings.NewReader(tt.body))
+			err := decodeJSON(req, &registerRequest{})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegisterHandler_BodyTooLarge(t *testing.T) {
+	h := NewAuthHandler(nil)
+
+	body := `{"email": "test@example.com", "password": "` + strings.Repeat("a", 100) + `"}`
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/register", strings.NewReader(body))
+	req.Body = http.MaxBytesReader(res, req.Body, 32)
+	h.RegisterHandler(res, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	assert.JSONEq(t, `{"message": "request body too large"}`, res.Body.String())
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import (
+	"context"
+	"pkg/logger"
+)
+
+// LogMailer 메일 서버를 붙이기 전까지 보낼 내용을 로그로 남긴다.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) SendRegistered(ctx context.Context, email string) error {
+	logger.FromContext(ctx).Info("send mail", "to", email, "kind", "registered")
+	return nil
+}
+
+func (m *LogMailer) SendAlreadyRegistered(ctx context.Context, email string) error {
+	logger.FromContext(ctx).Info("send mail", "to", email, "kind", "already_registered")
+	return nil
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Auth 인증 서비스의 비즈니스 지표
+type Auth struct {
+	registrations prometheus.Counter
+	logins        *prometheus.CounterVec
+	tokensIssued  prometheus.Counter
+	tokensRevoked prometheus.Counter
+}
+
+func NewAuth(reg prometheus.Registerer) *Auth {
+	m := &Auth{
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth_registrations_total",
+			Help: "Number of created accounts including guests.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_logins_total",
+			Help: "Number of login attempts by outcome.",
+		}, []string{"outcome"}),
+		tokensIssued: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth_tokens_issued_total",
+			Help: "Number of issued JWT tokens.",
+		}),
+		tokensRevoked: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth_tokens_revoked_total",
+			Help: "Number of revoked JWT tokens.",
+		}),
+	}
+	reg.MustRegister(m.registrations, m.logins, m.tokensIssued, m.tokensRevoked)
+	return m
+}
+
+func (m *Auth) IncRegistrations() {
+	m.registrations.Inc()
+}
+
+func (m *Auth) IncLogins(outcome string) {
+	m.logins.WithLabelValues(outcome).Inc()
+}
+
+func (m *Auth) IncTokensIssued() {
+	m.tokensIssued.Inc()
+}
+
+func (m *Auth) IncTokensRevoked() {
+	m.tokensRevoked.Inc()
+}
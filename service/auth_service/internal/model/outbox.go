@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// outbox 메시지 종류
+const (
+	OutboxSessionSave    = "session.save"
+	OutboxSessionDelete  = "session.delete"
+	OutboxUserRegistered = "user.registered"
+	OutboxGuestUpgraded  = "guest.upgraded"
+)
+
+// OutboxMessage DB 변경과 같은 트랜잭션에 기록하고 relay 가 나중에 적용하는 부수 효과.
+// 같은 OrderingKey 의 메시지는 기록 순서대로 적용한다.
+type OutboxMessage struct {
+	ID          int64     `db:"id"`
+	Kind        string    `db:"kind"`
+	OrderingKey string    `db:"ordering_key"`
+	Payload     []byte    `db:"payload"`
+	Attempts    int       `db:"attempts"`
+	AvailableAt time.Time `db:"available_at"`
+}
+
+// SessionPayload Redis 에 저장하거나 삭제할 세션. 삭제할 때는 Key 만 사용한다.
+type SessionPayload struct {
+	Key      string    `json:"key"`
+	Token    string    `json:"token,omitempty"`
+	ExpireAt time.Time `json:"expire_at,omitzero"`
+}
+
+// OrderingKey 같은 세션의 저장과 삭제는 순서를 지킨다.
+func (p SessionPayload) OrderingKey() string {
+	return p.Key
+}
+
+// UserEventPayload 계정에 관한 도메인 이벤트
+type UserEventPayload struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// OrderingKey 같은 계정의 이벤트는 순서를 지킨다.
+func (p UserEventPayload) OrderingKey() string {
+	return "user:" + strconv.FormatInt(p.UserID, 10)
+}
+
+// orderedPayload 순서를 지켜야 하는 메시지의 범위를 정한다. 구현하지 않으면 종류마다 순서를 지킨다.
+type orderedPayload interface {
+	OrderingKey() string
+}
+
+func NewOutboxMessage(kind string, payload any, availableAt time.Time) (*OutboxMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	key := kind
+	if p, ok := payload.(orderedPayload); ok {
+		key = p.OrderingKey()
+	}
+	return &OutboxMessage{Kind: kind, OrderingKey: key, Payload: data, AvailableAt: availableAt}, nil
+}
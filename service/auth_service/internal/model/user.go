@@ -2,11 +2,25 @@ package model
 
 import "time"
 
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+	RoleGuest = "guest"
+)
+
+// ValidRole 운영자가 지정할 수 있는 역할인지 확인한다. 게스트 역할은 게스트 가입으로만 만든다.
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleUser
+}
+
 type User struct {
 	ID        int64     `db:"id"`
 	Email     string    `db:"email"`
 	Password  string    `db:"password"`
 	Role      string    `db:"role"`
+	DeviceID  string    `db:"device_id"`
 	CreatedAt time.Time `db:"created_at"`
 	LastLogin time.Time `db:"last_login"`
+	// 비활성화된 계정은 로그인할 수 없다.
+	DisabledAt *time.Time `db:"disabled_at"`
 }
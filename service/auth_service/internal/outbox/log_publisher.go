@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"context"
+	"pkg/logger"
+)
+
+// LogPublisher 메시지 브로커를 붙이기 전까지 이벤트를 로그로 남긴다.
+type LogPublisher struct{}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event Event) error {
+	logger.FromContext(ctx).Info("publish event", "event_id", event.ID, "kind", event.Kind, "payload", string(event.Payload))
+	return nil
+}
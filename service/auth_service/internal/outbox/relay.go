@@ -0,0 +1,189 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"auth_service/internal/model"
+	"auth_service/internal/repository"
+	"pkg/clock"
+	"pkg/mysqlconn"
+)
+
+const (
+	// maxAttempts 이 횟수만큼 실패한 메시지는 버린다. 세션 삭제는 폐기가 사라지지 않도록 버리지 않는다.
+	maxAttempts    = 10
+	initialBackoff = time.Second
+	maxBackoff     = time.Minute
+	// claimLease 점유한 메시지를 이 시간 안에 적용하지 못하면 다른 relay 가 가져갈 수 있다.
+	// 적용은 절반의 시간 안에 끝내서 만료된 뒤에 늦게 적용되지 않게 한다.
+	claimLease = 30 * time.Second
+)
+
+// errInvalidMessage 다시 시도해도 적용할 수 없는 메시지
+var errInvalidMessage = errors.New("invalid outbox message")
+
+type Repository interface {
+	Claim(ctx context.Context, tx repository.QueryExecer, now, until time.Time, token string, limit int) ([]model.OutboxMessage, error)
+	Delete(ctx context.Context, exec repository.Execer, id int64, token string) error
+	Retry(ctx context.Context, exec repository.Execer, id int64, token string, availableAt time.Time, cause error) error
+}
+
+type RedisClient interface {
+	SaveUntil(ctx context.Context, key, value string, expireAt time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Event 도메인 이벤트. 같은 이벤트가 두 번 이상 전달될 수 있으므로 구독자는 ID 로 중복을 거른다.
+type Event struct {
+	ID      int64           `json:"id"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Relay outbox 메시지를 순서 키마다 기록 순서대로 적용하고 지운다.
+// 같은 키의 앞 메시지가 남아 있으면 뒤의 메시지를 적용하지 않으므로 같은 세션의 저장과 삭제가 뒤바뀌지 않는다.
+// 메시지는 짧은 트랜잭션으로 점유만 하고 트랜잭션 밖에서 적용하므로 Redis 가 느려도 기록하는 쪽을 막지 않는다.
+// 적용과 삭제 사이에 중단되면 다시 적용하므로 모든 부수 효과는 멱등이어야 한다.
+type Relay struct {
+	db        *sqlx.DB
+	repo      Repository
+	redis     RedisClient
+	publisher Publisher
+	clock     clock.Clock
+	interval  time.Duration
+	batchSize int
+	wake      chan struct{}
+}
+
+func NewRelay(db *sqlx.DB, repo Repository, redis RedisClient, publisher Publisher, interval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		db:        db,
+		repo:      repo,
+		redis:     redis,
+		publisher: publisher,
+		clock:     clock.Real{},
+		interval:  interval,
+		batchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify 새 메시지가 커밋되었음을 알려 다음 주기를 기다리지 않고 처리하게 한다.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run ctx 가 끝날 때까지 주기마다, 그리고 Notify 를 받을 때마다 메시지를 처리한다.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-r.wake:
+		}
+
+		// 앞 메시지를 지우면 같은 키의 다음 메시지를 가져올 수 있으므로 가져올 것이 없을 때까지 처리한다.
+		for {
+			n, err := r.Process(ctx)
+			if err != nil {
+				slog.Error("failed to process outbox", "error", err)
+				break
+			}
+			if n == 0 {
+				break
+			}
+		}
+	}
+}
+
+// Process 처리할 수 있는 메시지를 한 번 점유해서 적용하고 점유한 개수를 반환한다.
+// 실패한 메시지는 재시도 시각까지 같은 키의 뒤 메시지를 막고 다른 키는 막지 않는다.
+func (r *Relay) Process(ctx context.Context) (int, error) {
+	now := r.clock.Now()
+	token := uuid.NewString()
+
+	var msgs []model.OutboxMessage
+	// READ COMMITTED 는 갭 잠금을 걸지 않아서 점유하는 동안에도 새 메시지를 기록할 수 있다.
+	err := mysqlconn.WithTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(tx mysqlconn.Tx) error {
+		var err error
+		msgs, err = r.repo.Claim(ctx, tx, now, now.Add(claimLease), token, r.batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox: %w", err)
+	}
+
+	applyCtx, cancel := context.WithTimeout(ctx, claimLease/2)
+	defer cancel()
+	for _, msg := range msgs {
+		if err := r.process(applyCtx, ctx, msg, token, now); err != nil {
+			return len(msgs), err
+		}
+	}
+	return len(msgs), nil
+}
+
+// process 메시지 하나를 적용하고 결과를 기록한다. 적용 시간이 지나면 점유가 만료된 뒤 다시 처리된다.
+func (r *Relay) process(applyCtx, ctx context.Context, msg model.OutboxMessage, token string, now time.Time) error {
+	if applyCtx.Err() != nil {
+		return nil
+	}
+
+	l := slog.With("outbox_id", msg.ID, "kind", msg.Kind, "attempts", msg.Attempts+1)
+	if err := r.apply(applyCtx, msg); err != nil {
+		if !errors.Is(err, errInvalidMessage) && (msg.Kind == model.OutboxSessionDelete || msg.Attempts+1 < maxAttempts) {
+			backoff := min(initialBackoff<<min(msg.Attempts, 16), maxBackoff)
+			l.Warn("failed to apply outbox message, retrying", "backoff", backoff, "error", err)
+			if err := r.repo.Retry(ctx, r.db, msg.ID, token, now.Add(backoff), err); err != nil {
+				return fmt.Errorf("failed to retry outbox message %d: %w", msg.ID, err)
+			}
+			return nil
+		}
+		l.Error("dropping outbox message", "error", err)
+	}
+	if err := r.repo.Delete(ctx, r.db, msg.ID, token); err != nil {
+		return fmt.Errorf("failed to delete outbox message %d: %w", msg.ID, err)
+	}
+	return nil
+}
+
+func (r *Relay) apply(ctx context.Context, msg model.OutboxMessage) error {
+	switch msg.Kind {
+	case model.OutboxSessionSave:
+		var p model.SessionPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidMessage, err)
+		}
+		return r.redis.SaveUntil(ctx, p.Key, p.Token, p.ExpireAt)
+	case model.OutboxSessionDelete:
+		var p model.SessionPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidMessage, err)
+		}
+		return r.redis.Delete(ctx, p.Key)
+	case model.OutboxUserRegistered, model.OutboxGuestUpgraded:
+		return r.publisher.Publish(ctx, Event{ID: msg.ID, Kind: msg.Kind, Payload: msg.Payload})
+	default:
+		return fmt.Errorf("%w: unknown kind %q", errInvalidMessage, msg.Kind)
+	}
+}
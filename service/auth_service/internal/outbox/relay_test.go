@@ -0,0 +1,214 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"auth_service/internal/model"
+	"auth_service/internal/repository"
+	"pkg/clock"
+)
+
+var testNow = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Claim(ctx context.Context, tx repository.QueryExecer, now, until time.Time, token string, limit int) ([]model.OutboxMessage, error) {
+	args := m.Called(ctx, tx, now, until, token, limit)
+	return args.Get(0).([]model.OutboxMessage), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, exec repository.Execer, id int64, token string) error {
+	return m.Called(ctx, exec, id, token).Error(0)
+}
+
+func (m *MockRepository) Retry(ctx context.Context, exec repository.Execer, id int64, token string, availableAt time.Time, cause error) error {
+	return m.Called(ctx, exec, id, token, availableAt, cause).Error(0)
+}
+
+type MockRedisClient struct {
+	mock.Mock
+}
+
+func (m *MockRedisClient) SaveUntil(ctx context.Context, key, value string, expireAt time.Time) error {
+	return m.Called(ctx, key, value, expireAt).Error(0)
+}
+
+func (m *MockRedisClient) Delete(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, event Event) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func newMessage(t *testing.T, id int64, kind string, payload any, attempts int) model.OutboxMessage {
+	t.Helper()
+	msg, err := model.NewOutboxMessage(kind, payload, testNow)
+	assert.NoError(t, err)
+	msg.ID = id
+	msg.Attempts = attempts
+	return *msg
+}
+
+func newTestRelay(t *testing.T, repo Repository, redis RedisClient, publisher Publisher) (*Relay, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	r := NewRelay(sqlx.NewDb(db, "mysql"), repo, redis, publisher, time.Second, 10)
+	r.clock = clock.NewFake(testNow)
+	return r, mockDB
+}
+
+// expectClaim 점유는 짧은 트랜잭션에서 끝나고 적용은 트랜잭션 밖에서 한다.
+func expectClaim(mockRepo *MockRepository, mockDB sqlmock.Sqlmock, msgs []model.OutboxMessage) {
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+	mockRepo.On("Claim", mock.Anything, mock.Anything, testNow, testNow.Add(claimLease), mock.AnythingOfType("string"), 10).
+		Return(msgs, nil).
+		Once()
+}
+
+func TestRelay_Process(t *testing.T) {
+	ctx := context.Background()
+	expireAt := testNow.Add(time.Hour)
+	msgs := []model.OutboxMessage{
+		newMessage(t, 1, model.OutboxSessionSave, model.SessionPayload{Key: "jwt:a", Token: "token", ExpireAt: expireAt}, 0),
+		newMessage(t, 2, model.OutboxSessionDelete, model.SessionPayload{Key: "jwt:b"}, 0),
+		newMessage(t, 3, model.OutboxUserRegistered, model.UserEventPayload{UserID: 7, Role: model.RoleAdmin}, 0),
+	}
+
+	mockRepo := new(MockRepository)
+	for _, msg := range msgs {
+		mockRepo.On("Delete", ctx, mock.Anything, msg.ID, mock.AnythingOfType("string")).Return(nil)
+	}
+	mockRedis := new(MockRedisClient)
+	mockRedis.On("SaveUntil", mock.Anything, "jwt:a", "token", expireAt).Return(nil)
+	mockRedis.On("Delete", mock.Anything, "jwt:b").Return(nil)
+	mockPublisher := new(MockPublisher)
+	mockPublisher.On("Publish", mock.Anything, Event{ID: 3, Kind: model.OutboxUserRegistered, Payload: msgs[2].Payload}).Return(nil)
+
+	r, mockDB := newTestRelay(t, mockRepo, mockRedis, mockPublisher)
+	expectClaim(mockRepo, mockDB, msgs)
+
+	n, err := r.Process(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	mockRepo.AssertExpectations(t)
+	mockRedis.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+
+	// 점유한 token 으로만 지운다.
+	token := mockRepo.Calls[0].Arguments.String(4)
+	for _, call := range mockRepo.Calls[1:] {
+		assert.Equal(t, token, call.Arguments.String(3))
+	}
+}
+
+func TestRelay_Process_Retry(t *testing.T) {
+	ctx := context.Background()
+	cause := errors.New("publisher is down")
+	msgs := []model.OutboxMessage{
+		newMessage(t, 1, model.OutboxUserRegistered, model.UserEventPayload{UserID: 7, Role: model.RoleUser}, 2),
+		newMessage(t, 2, model.OutboxSessionDelete, model.SessionPayload{Key: "jwt:b"}, 0),
+	}
+
+	// 실패한 메시지는 시도 횟수만큼 늦추고, 다른 키의 메시지는 기다리지 않고 적용한다.
+	mockRepo := new(MockRepository)
+	mockRepo.On("Retry", ctx, mock.Anything, int64(1), mock.AnythingOfType("string"), testNow.Add(4*time.Second), cause).Return(nil)
+	mockRepo.On("Delete", ctx, mock.Anything, int64(2), mock.AnythingOfType("string")).Return(nil)
+	mockRedis := new(MockRedisClient)
+	mockRedis.On("Delete", mock.Anything, "jwt:b").Return(nil)
+	mockPublisher := new(MockPublisher)
+	mockPublisher.On("Publish", mock.Anything, mock.Anything).Return(cause)
+
+	r, mockDB := newTestRelay(t, mockRepo, mockRedis, mockPublisher)
+	expectClaim(mockRepo, mockDB, msgs)
+
+	n, err := r.Process(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	mockRepo.AssertExpectations(t)
+	mockRedis.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Delete", ctx, mock.Anything, int64(1), mock.Anything)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRelay_Process_Drop(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]model.OutboxMessage{
+		"unknown kind": {ID: 1, Kind: "unknown", Payload: []byte(`{}`)},
+		"invalid json": {ID: 1, Kind: model.OutboxSessionSave, Payload: []byte(`{`)},
+		"last attempt": newMessage(t, 1, model.OutboxSessionSave, model.SessionPayload{Key: "jwt:a", Token: "token"}, maxAttempts-1),
+	}
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("Delete", ctx, mock.Anything, msg.ID, mock.AnythingOfType("string")).Return(nil)
+			mockRedis := new(MockRedisClient)
+			mockRedis.On("SaveUntil", mock.Anything, "jwt:a", "token", mock.Anything).Return(errors.New("redis is down"))
+
+			r, mockDB := newTestRelay(t, mockRepo, mockRedis, nil)
+			expectClaim(mockRepo, mockDB, []model.OutboxMessage{msg})
+
+			n, err := r.Process(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "Retry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRelay_Process_KeepDelete(t *testing.T) {
+	ctx := context.Background()
+	cause := errors.New("redis is down")
+	msg := newMessage(t, 1, model.OutboxSessionDelete, model.SessionPayload{Key: "jwt:a"}, maxAttempts+5)
+
+	// 세션 삭제는 시도 횟수를 넘겨도 버리지 않고 가장 긴 간격으로 다시 시도한다.
+	mockRepo := new(MockRepository)
+	mockRepo.On("Retry", ctx, mock.Anything, int64(1), mock.AnythingOfType("string"), testNow.Add(maxBackoff), cause).Return(nil)
+	mockRedis := new(MockRedisClient)
+	mockRedis.On("Delete", mock.Anything, "jwt:a").Return(cause)
+
+	r, mockDB := newTestRelay(t, mockRepo, mockRedis, nil)
+	expectClaim(mockRepo, mockDB, []model.OutboxMessage{msg})
+
+	_, err := r.Process(ctx)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRelay_Process_ClaimError(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepository)
+	mockRepo.On("Claim", mock.Anything, mock.Anything, testNow, testNow.Add(claimLease), mock.AnythingOfType("string"), 10).
+		Return([]model.OutboxMessage(nil), sql.ErrConnDone)
+
+	r, mockDB := newTestRelay(t, mockRepo, nil, nil)
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+
+	_, err := r.Process(ctx)
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
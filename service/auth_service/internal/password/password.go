@@ -0,0 +1,181 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+const (
+	argon2SaltSize = 16
+	argon2KeySize  = 32
+	// 저장된 해시가 이보다 짧으면 손상되었거나 조작된 것으로 본다. 길이 0인 키는 모든 비밀번호와 일치한다.
+	minArgon2SaltSize = 8
+	minArgon2KeySize  = 16
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+
+// Config 새로 만드는 해시에 사용할 알고리즘과 파라미터
+type Config struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32 // KiB
+	Argon2Threads uint8
+}
+
+// Hasher 비밀번호 해시를 만들고 검증한다.
+// 해시 문자열은 알고리즘 식별자로 시작하므로 설정이 바뀌어도 기존 해시를 검증할 수 있다.
+//   - bcrypt: $2a$10$...
+//   - argon2id: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+type Hasher struct {
+	config Config
+}
+
+func New(config Config) (*Hasher, error) {
+	switch config.Algorithm {
+	case Bcrypt:
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost: %d", config.BcryptCost)
+		}
+	case Argon2id:
+		if config.Argon2Time == 0 || config.Argon2Memory == 0 || config.Argon2Threads == 0 {
+			return nil, fmt.Errorf("invalid argon2id parameters: t=%d m=%d p=%d",
+				config.Argon2Time, config.Argon2Memory, config.Argon2Threads)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, config.Algorithm)
+	}
+
+	return &Hasher{config: config}, nil
+}
+
+// Hash 설정된 알고리즘으로 해시를 만든다.
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.config.Algorithm == Argon2id {
+		return h.hashArgon2id(password)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.config.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify 해시에 기록된 알고리즘으로 비밀번호를 비교한다.
+func (h *Hasher) Verify(hash, password string) (bool, error) {
+	switch algorithm(hash) {
+	case Bcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case Argon2id:
+		p, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	default:
+		return false, ErrUnknownAlgorithm
+	}
+}
+
+// NeedsRehash 해시가 현재 설정과 다른 알고리즘이나 파라미터로 만들어졌는지 확인한다.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if algorithm(hash) != h.config.Algorithm {
+		return true
+	}
+
+	if h.config.Algorithm == Argon2id {
+		p, _, _, err := decodeArgon2id(hash)
+		return err != nil ||
+			p.time != h.config.Argon2Time ||
+			p.memory != h.config.Argon2Memory ||
+			p.threads != h.config.Argon2Threads
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.config.BcryptCost
+}
+
+func algorithm(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return Bcrypt
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return Argon2id
+	default:
+		return ""
+	}
+}
+
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func (h *Hasher) hashArgon2id(password string) (string, error) {
+	salt := make([]byte, argon2SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	c := h.config
+	key := argon2.IDKey([]byte(password), salt, c.Argon2Time, c.Argon2Memory, c.Argon2Threads, argon2KeySize)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, c.Argon2Memory, c.Argon2Time, c.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func decodeArgon2id(hash string) (p argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2id version: %d", version)
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if p.time < 1 || p.threads < 1 {
+		return p, nil, nil, fmt.Errorf("invalid argon2id parameters: %s", parts[3])
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	if len(salt) < minArgon2SaltSize || len(key) < minArgon2KeySize {
+		return p, nil, nil, fmt.Errorf("invalid argon2id hash: salt or key is too short")
+	}
+
+	return p, salt, key, nil
+}
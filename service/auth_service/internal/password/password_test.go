@@ -0,0 +1,112 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	bcryptConfig   = Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost}
+	argon2idConfig = Config{Algorithm: Argon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1}
+)
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config Config
+		prefix string
+	}{
+		"bcrypt":   {config: bcryptConfig, prefix: "$2a$"},
+		"argon2id": {config: argon2idConfig, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h, err := New(tt.config)
+			assert.NoError(t, err)
+
+			hash, err := h.Hash("password123")
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+
+			ok, err := h.Verify(hash, "password123")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = h.Verify(hash, "wrongpassword")
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	t.Parallel()
+
+	oldBcrypt, err := New(bcryptConfig)
+	assert.NoError(t, err)
+	bcryptHash, err := oldBcrypt.Hash("password123")
+	assert.NoError(t, err)
+
+	oldArgon2id, err := New(argon2idConfig)
+	assert.NoError(t, err)
+	argon2idHash, err := oldArgon2id.Hash("password123")
+	assert.NoError(t, err)
+
+	strongerBcrypt, err := New(Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost + 1})
+	assert.NoError(t, err)
+	assert.True(t, strongerBcrypt.NeedsRehash(bcryptHash))
+	assert.True(t, strongerBcrypt.NeedsRehash(argon2idHash))
+
+	strongerArgon2id, err := New(Config{Algorithm: Argon2id, Argon2Time: 2, Argon2Memory: 1024, Argon2Threads: 1})
+	assert.NoError(t, err)
+	assert.True(t, strongerArgon2id.NeedsRehash(argon2idHash))
+	assert.True(t, strongerArgon2id.NeedsRehash(bcryptHash))
+
+	// 다른 알고리즘으로 만든 해시도 검증할 수 있어야 한다.
+	ok, err := strongerArgon2id.Verify(bcryptHash, "password123")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Config{Algorithm: "md5"})
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+
+	_, err = New(Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MaxCost + 1})
+	assert.Error(t, err)
+
+	_, err = New(Config{Algorithm: Argon2id})
+	assert.Error(t, err)
+}
+
+func TestHasher_VerifyInvalidArgon2id(t *testing.T) {
+	t.Parallel()
+
+	h, err := New(argon2idConfig)
+	assert.NoError(t, err)
+
+	salt := "c29tZXNhbHRzb21lc2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	tests := map[string]string{
+		"empty key":    "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$",
+		"short key":    "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$a2V5",
+		"empty salt":   "$argon2id$v=19$m=1024,t=1,p=1$$" + key,
+		"zero time":    "$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+		"zero threads": "$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+	}
+	for name, hash := range tests {
+		t.Run(name, func(t *testing.T) {
+			ok, err := h.Verify(hash, "anything")
+			assert.Error(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
@@ -13,3 +13,9 @@ type Queryer interface {
 type Execer interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
+
+// QueryExecer 조회와 변경을 함께 하는 트랜잭션
+type QueryExecer interface {
+	Queryer
+	Execer
+}
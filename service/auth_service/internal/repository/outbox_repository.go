@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+
+	"auth_service/internal/model"
+)
+
+// maxErrorLength outbox.last_error 컬럼 길이
+const maxErrorLength = 512
+
+type OutboxRepository struct{}
+
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{}
+}
+
+// Add 호출한 쪽의 트랜잭션으로 메시지를 기록한다.
+func (r *OutboxRepository) Add(ctx context.Context, exec Execer, msg *model.OutboxMessage) error {
+	query := "INSERT INTO outbox (kind, ordering_key, payload, available_at) VALUES (?, ?, ?, ?)"
+	ctx, end := startSpan(ctx, "OutboxRepository.Add", query)
+	result, err := exec.ExecContext(ctx, query, msg.Kind, msg.OrderingKey, msg.Payload, msg.AvailableAt)
+	end(err)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	msg.ID = id
+	return nil
+}
+
+// Claim 순서 키마다 가장 먼저 기록된 메시지 중 처리할 수 있는 것을 limit 개까지 token 으로 until 까지 점유한다.
+// 앞의 메시지가 남아 있는 키는 건너뛰므로 실패한 메시지는 같은 키의 뒤 메시지만 막는다.
+// 다른 relay 가 잠근 행은 건너뛰고, 점유를 기록하면 바로 커밋할 수 있도록 짧은 트랜잭션 안에서 호출해야 한다.
+func (r *OutboxRepository) Claim(ctx context.Context, tx QueryExecer, now, until time.Time, token string, limit int) ([]model.OutboxMessage, error) {
+	var msgs []model.OutboxMessage
+	query := "SELECT o.id, o.kind, o.ordering_key, o.payload, o.attempts, o.available_at FROM outbox o " +
+		"WHERE o.available_at <= ? AND (o.claimed_until IS NULL OR o.claimed_until <= ?) " +
+		"AND NOT EXISTS (SELECT 1 FROM outbox p WHERE p.ordering_key = o.ordering_key AND p.id < o.id) " +
+		"ORDER BY o.id LIMIT ? FOR UPDATE SKIP LOCKED"
+	selectCtx, end := startSpan(ctx, "OutboxRepository.Claim", query)
+	err := tx.SelectContext(selectCtx, &msgs, query, now, now, limit)
+	end(err)
+	if err != nil || len(msgs) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int64, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	query, args, err := sqlx.In("UPDATE outbox SET claimed_until = ?, claim_token = ? WHERE id IN (?)", until, token, ids)
+	if err != nil {
+		return nil, err
+	}
+	ctx, end = startSpan(ctx, "OutboxRepository.Claim", query)
+	_, err = tx.ExecContext(ctx, query, args...)
+	end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// Delete 적용한 메시지를 지운다. 점유가 만료되어 다른 relay 가 가져갔으면 sql.ErrNoRows 를 반환한다.
+func (r *OutboxRepository) Delete(ctx context.Context, exec Execer, id int64, token string) error {
+	query := "DELETE FROM outbox WHERE id = ? AND claim_token = ?"
+	ctx, end := startSpan(ctx, "OutboxRepository.Delete", query)
+	result, err := exec.ExecContext(ctx, query, id, token)
+	end(err)
+	if err != nil {
+		return err
+	}
+	return affected(result)
+}
+
+// Retry 실패한 메시지의 점유를 풀고 availableAt 이후에 다시 처리하도록 기록한다.
+func (r *OutboxRepository) Retry(ctx context.Context, exec Execer, id int64, token string, availableAt time.Time, cause error) error {
+	msg := cause.Error()
+	if len(msg) > maxErrorLength {
+		// 여러 바이트 문자 중간에서 자르면 utf8mb4 컬럼이 값을 거부하므로 문자 경계에서 자른다.
+		cut := maxErrorLength
+		for cut > 0 && !utf8.RuneStart(msg[cut]) {
+			cut--
+		}
+		msg = msg[:cut]
+	}
+
+	query := "UPDATE outbox SET attempts = attempts + 1, available_at = ?, last_error = ?, claimed_until = NULL, claim_token = NULL " +
+		"WHERE id = ? AND claim_token = ?"
+	ctx, end := startSpan(ctx, "OutboxRepository.Retry", query)
+	result, err := exec.ExecContext(ctx, query, availableAt, msg, id, token)
+	end(err)
+	if err != nil {
+		return err
+	}
+	return affected(result)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"auth_service/internal/model"
+)
+
+func TestOutboxRepository_Add(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	now := time.Now()
+	msg, err := model.NewOutboxMessage(model.OutboxSessionDelete, model.SessionPayload{Key: "jwt:test"}, now)
+	assert.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectExec(`INSERT INTO outbox \(kind, ordering_key, payload, available_at\) VALUES \(\?, \?, \?, \?\)`).
+		WithArgs(model.OutboxSessionDelete, "jwt:test", []byte(`{"key":"jwt:test"}`), now).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	r := NewOutboxRepository()
+	assert.NoError(t, r.Add(ctx, sqlx.NewDb(db, "mysql"), msg))
+	assert.Equal(t, int64(3), msg.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Claim(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	now := time.Now()
+	until := now.Add(30 * time.Second)
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	// 키마다 가장 앞의 메시지만 가져오고 가져온 메시지를 token 으로 점유한다.
+	mock.ExpectQuery(`SELECT .+ FROM outbox o WHERE o.available_at <= \? AND \(o.claimed_until IS NULL OR o.claimed_until <= \?\) `+
+		`AND NOT EXISTS \(SELECT 1 FROM outbox p WHERE p.ordering_key = o.ordering_key AND p.id < o.id\) ORDER BY o.id LIMIT \? FOR UPDATE SKIP LOCKED`).
+		WithArgs(now, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "ordering_key", "payload", "attempts", "available_at"}).
+			AddRow(1, model.OutboxSessionSave, "jwt:a", []byte(`{}`), 0, now).
+			AddRow(3, model.OutboxUserRegistered, "user:7", []byte(`{}`), 2, now))
+	mock.ExpectExec(`UPDATE outbox SET claimed_until = \?, claim_token = \? WHERE id IN \(\?, \?\)`).
+		WithArgs(until, "token", 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	r := NewOutboxRepository()
+	msgs, err := r.Claim(ctx, sqlx.NewDb(db, "mysql"), now, until, "token", 10)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, "user:7", msgs[1].OrderingKey)
+	assert.Equal(t, 2, msgs[1].Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Delete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectExec(`DELETE FROM outbox WHERE id = \? AND claim_token = \?`).
+		WithArgs(1, "token").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 점유가 만료되어 다른 relay 가 가져간 메시지는 지우지 않는다.
+	mock.ExpectExec(`DELETE FROM outbox WHERE id = \? AND claim_token = \?`).
+		WithArgs(2, "token").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := NewOutboxRepository()
+	assert.NoError(t, r.Delete(ctx, sqlx.NewDb(db, "mysql"), 1, "token"))
+	assert.ErrorIs(t, r.Delete(ctx, sqlx.NewDb(db, "mysql"), 2, "token"), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Retry(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	next := time.Now().Add(time.Second)
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	// 컬럼 길이를 넘는 오류 메시지는 잘라서 기록한다.
+	mock.ExpectExec(`UPDATE outbox SET attempts = attempts \+ 1, available_at = \?, last_error = \?, claimed_until = NULL, claim_token = NULL WHERE id = \? AND claim_token = \?`).
+		WithArgs(next, strings.Repeat("x", maxErrorLength), 1, "token").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// 여러 바이트 문자는 나누지 않는다.
+	mock.ExpectExec(`UPDATE outbox SET attempts = attempts \+ 1, available_at = \?, last_error = \?, claimed_until = NULL, claim_token = NULL WHERE id = \? AND claim_token = \?`).
+		WithArgs(next, "x"+strings.Repeat("가", (maxErrorLength-1)/3), 2, "token").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := NewOutboxRepository()
+	assert.NoError(t, r.Retry(ctx, sqlx.NewDb(db, "mysql"), 1, "token", next, errors.New(strings.Repeat("x", 600))))
+	assert.NoError(t, r.Retry(ctx, sqlx.NewDb(db, "mysql"), 2, "token", next, errors.New("x"+strings.Repeat("가", 300))))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
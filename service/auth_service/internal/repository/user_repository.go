@@ -2,10 +2,27 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"auth_service/internal/model"
 )
 
+// ER_DUP_ENTRY
+const mysqlErrDuplicateEntry = 1062
+
+// ErrDuplicateEmail 이메일 유니크 제약 조건 위반. 동시에 가입하는 경우 먼저 조회하더라도 발생할 수 있다.
+var ErrDuplicateEmail = errors.New("duplicate email")
+
+var tracer = otel.Tracer("auth_service/internal/repository")
+
 type UserRepository struct{}
 
 func NewUserRepository() *UserRepository {
@@ -14,9 +31,11 @@ func NewUserRepository() *UserRepository {
 
 func (r *UserRepository) CreateUser(ctx context.Context, exec Execer, user *model.User) error {
 	query := "INSERT INTO account (email, password, role, created_at, last_login) VALUES (?, ?, ?, ?, ?)"
+	ctx, end := startSpan(ctx, "UserRepository.CreateUser", query)
 	result, err := exec.ExecContext(ctx, query, user.Email, user.Password, user.Role, user.CreatedAt, user.LastLogin)
+	end(err)
 	if err != nil {
-		return err
+		return duplicateEmail(err)
 	}
 
 	id, err := result.LastInsertId()
@@ -30,8 +49,10 @@ func (r *UserRepository) CreateUser(ctx context.Context, exec Execer, user *mode
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, q Queryer, email string) (*model.User, error) {
 	var user model.User
-	query := "SELECT id, email, password, role, created_at, last_login FROM account WHERE email = ?"
+	query := "SELECT id, email, password, role, created_at, last_login, disabled_at FROM account WHERE email = ?"
+	ctx, end := startSpan(ctx, "UserRepository.GetUserByEmail", query)
 	err := q.GetContext(ctx, &user, query, email)
+	end(err)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +62,161 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, q Queryer, email st
 
 func (r *UserRepository) UpdateLastLogin(ctx context.Context, exec Execer, user *model.User) error {
 	query := "UPDATE account SET last_login = ? WHERE id = ?"
+	ctx, end := startSpan(ctx, "UserRepository.UpdateLastLogin", query)
 	_, err := exec.ExecContext(ctx, query, user.LastLogin, user.ID)
+	end(err)
 	return err
 }
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, exec Execer, user *model.User) error {
+	query := "UPDATE account SET password = ? WHERE id = ?"
+	ctx, end := startSpan(ctx, "UserRepository.UpdatePassword", query)
+	_, err := exec.ExecContext(ctx, query, user.Password, user.ID)
+	end(err)
+	return err
+}
+
+func (r *UserRepository) CreateGuest(ctx context.Context, exec Execer, user *model.User) error {
+	query := "INSERT INTO account (password, role, device_id, created_at, last_login) VALUES (?, ?, ?, ?, ?)"
+	ctx, end := startSpan(ctx, "UserRepository.CreateGuest", query)
+	result, err := exec.ExecContext(ctx, query, user.Password, user.Role, user.DeviceID, user.CreatedAt, user.LastLogin)
+	end(err)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	user.ID = id
+	return nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, q Queryer, id int64) (*model.User, error) {
+	var user model.User
+	query := "SELECT id, COALESCE(email, '') AS email, password, role, COALESCE(device_id, '') AS device_id, created_at, last_login, disabled_at FROM account WHERE id = ?"
+	ctx, end := startSpan(ctx, "UserRepository.GetUserByID", query)
+	err := q.GetContext(ctx, &user, query, id)
+	end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpgradeGuest 게스트 계정에 이메일과 비밀번호를 연결한다. 게스트가 아니면 sql.ErrNoRows 를 반환한다.
+func (r *UserRepository) UpgradeGuest(ctx context.Context, exec Execer, user *model.User) error {
+	query := "UPDATE account SET email = ?, password = ?, role = ?, device_id = NULL WHERE id = ? AND role = ?"
+	ctx, end := startSpan(ctx, "UserRepository.UpgradeGuest", query)
+	result, err := exec.ExecContext(ctx, query, user.Email, user.Password, user.Role, user.ID, model.RoleGuest)
+	end(err)
+	if err != nil {
+		return duplicateEmail(err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	user.DeviceID = ""
+	return nil
+}
+
+// DeleteStaleGuests before 이후로 로그인 기록이 없는 게스트 계정을 삭제한다.
+func (r *UserRepository) DeleteStaleGuests(ctx context.Context, exec Execer, before time.Time) (int64, error) {
+	query := "DELETE FROM account WHERE role = ? AND last_login < ?"
+	ctx, end := startSpan(ctx, "UserRepository.DeleteStaleGuests", query)
+	result, err := exec.ExecContext(ctx, query, model.RoleGuest, before)
+	end(err)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ListUsers afterID 다음부터 id 순으로 limit 개를 반환한다. 비밀번호 해시는 읽지 않는다.
+func (r *UserRepository) ListUsers(ctx context.Context, q Queryer, afterID int64, limit int) ([]model.User, error) {
+	var users []model.User
+	query := "SELECT id, COALESCE(email, '') AS email, role, COALESCE(device_id, '') AS device_id, created_at, last_login, disabled_at FROM account WHERE id > ? ORDER BY id LIMIT ?"
+	ctx, end := startSpan(ctx, "UserRepository.ListUsers", query)
+	err := q.SelectContext(ctx, &users, query, afterID, limit)
+	end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// UpdateRole 계정이 없으면 sql.ErrNoRows 를 반환한다.
+func (r *UserRepository) UpdateRole(ctx context.Context, exec Execer, user *model.User) error {
+	query := "UPDATE account SET role = ? WHERE id = ?"
+	ctx, end := startSpan(ctx, "UserRepository.UpdateRole", query)
+	result, err := exec.ExecContext(ctx, query, user.Role, user.ID)
+	end(err)
+	if err != nil {
+		return err
+	}
+	return affected(result)
+}
+
+// DisableUser 계정이 없으면 sql.ErrNoRows 를 반환한다.
+func (r *UserRepository) DisableUser(ctx context.Context, exec Execer, user *model.User) error {
+	query := "UPDATE account SET disabled_at = ? WHERE id = ?"
+	ctx, end := startSpan(ctx, "UserRepository.DisableUser", query)
+	result, err := exec.ExecContext(ctx, query, user.DisabledAt, user.ID)
+	end(err)
+	if err != nil {
+		return err
+	}
+	return affected(result)
+}
+
+// affected 바뀐 행이 없으면 sql.ErrNoRows 를 반환한다.
+// MySQL 은 값이 같으면 바뀐 행으로 세지 않으므로 호출하는 쪽에서 먼저 계정을 조회한다.
+func affected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// account 테이블의 유니크 키는 email 뿐이므로 중복 키 오류는 이메일 중복이다.
+func duplicateEmail(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == mysqlErrDuplicateEntry {
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+// startSpan 쿼리마다 span 을 만든다. 반환된 함수로 쿼리 결과를 기록하고 span 을 끝낸다.
+// 값은 민감할 수 있으므로 파라미터 바인딩 전 쿼리만 기록한다.
+func startSpan(ctx context.Context, name, query string) (context.Context, func(error)) {
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.statement", query),
+		),
+	)
+	return ctx, func(err error) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
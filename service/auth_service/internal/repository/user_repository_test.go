@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 
@@ -38,3 +40,165 @@ func TestUserRepository_CreateUser(t *testing.T) {
 		t.Fatalf("CreateUser() error = %v", err)
 	}
 }
+
+func TestUserRepository_CreateUser_DuplicateEmail(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	testUser := &model.User{Email: "test", Password: "test", Role: "role"}
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectExec(`INSERT INTO account`).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'test' for key 'account.email'"})
+	mock.ExpectExec(`INSERT INTO account`).
+		WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	assert.ErrorIs(t, r.CreateUser(ctx, xdb, testUser), ErrDuplicateEmail)
+
+	err = r.CreateUser(ctx, xdb, testUser)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDuplicateEmail)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_CreateGuest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	guest := &model.User{
+		Password:  "secret-hash",
+		Role:      model.RoleGuest,
+		DeviceID:  "device",
+		CreatedAt: time.Now(),
+		LastLogin: time.Now(),
+	}
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectExec(
+		`INSERT INTO account \(password, role, device_id, created_at, last_login\) VALUES \(\?, \?, \?, \?, \?\)`,
+	).WithArgs(guest.Password, guest.Role, guest.DeviceID, guest.CreatedAt, guest.LastLogin).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	if err = r.CreateGuest(ctx, xdb, guest); err != nil {
+		t.Fatalf("CreateGuest() error = %v", err)
+	}
+	assert.Equal(t, int64(7), guest.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpgradeGuest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	user := &model.User{ID: 7, Email: "test", Password: "test", Role: model.RoleAdmin, DeviceID: "device"}
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	query := `UPDATE account SET email = \?, password = \?, role = \?, device_id = NULL WHERE id = \? AND role = \?`
+	mock.ExpectExec(query).
+		WithArgs(user.Email, user.Password, user.Role, user.ID, model.RoleGuest).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).
+		WithArgs(user.Email, user.Password, user.Role, user.ID, model.RoleGuest).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	assert.NoError(t, r.UpgradeGuest(ctx, xdb, user))
+	assert.Empty(t, user.DeviceID)
+	assert.ErrorIs(t, r.UpgradeGuest(ctx, xdb, user), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_DeleteStaleGuests(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	before := time.Now()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectExec(`DELETE FROM account WHERE role = \? AND last_login < \?`).
+		WithArgs(model.RoleGuest, before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	n, err := r.DeleteStaleGuests(ctx, xdb, before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_ListUsers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	now := time.Now()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectQuery(`SELECT .+ FROM account WHERE id > \? ORDER BY id LIMIT \?`).
+		WithArgs(int64(1), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role", "device_id", "created_at", "last_login", "disabled_at"}).
+			AddRow(2, "test", model.RoleAdmin, "", now, now, nil).
+			AddRow(3, "", model.RoleGuest, "device", now, now, now))
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	users, err := r.ListUsers(ctx, xdb, 1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Nil(t, users[0].DisabledAt)
+	assert.Equal(t, "device", users[1].DeviceID)
+	assert.NotNil(t, users[1].DisabledAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdateRole(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	user := &model.User{ID: 7, Role: model.RoleUser}
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	query := `UPDATE account SET role = \? WHERE id = \?`
+	mock.ExpectExec(query).
+		WithArgs(user.Role, user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).
+		WithArgs(user.Role, user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	assert.NoError(t, r.UpdateRole(ctx, xdb, user))
+	assert.ErrorIs(t, r.UpdateRole(ctx, xdb, user), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_DisableUser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	now := time.Now()
+	user := &model.User{ID: 7, DisabledAt: &now}
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectExec(`UPDATE account SET disabled_at = \? WHERE id = \?`).
+		WithArgs(&now, user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	xdb := sqlx.NewDb(db, "mysql")
+	r := &UserRepository{}
+	assert.NoError(t, r.DisableUser(ctx, xdb, user))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
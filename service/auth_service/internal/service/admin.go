@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/model"
+	"auth_service/internal/repository"
+	"pkg/mysqlconn"
+)
+
+// 운영 명령에서 사용하는 오류
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
+// CreateUser 운영자가 역할을 지정해서 계정을 만든다. 가입 숨김 설정과 관계없이 중복을 알린다.
+func (s *AuthService) CreateUser(ctx context.Context, email, password, role string) (*model.User, error) {
+	if !model.ValidRole(role) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	user := &model.User{
+		Email:     email,
+		Password:  hash,
+		Role:      role,
+		CreatedAt: now,
+		LastLogin: now,
+	}
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		if err := s.userRepo.CreateUser(ctx, tx, user); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, tx, model.OutboxUserRegistered, model.UserEventPayload{UserID: user.ID, Role: user.Role})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return nil, ErrUserExists
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	s.notifier.Notify()
+
+	s.metrics.IncRegistrations()
+	return user, nil
+}
+
+// ListUsers afterID 다음 계정부터 limit 개를 반환한다. 복제 지연 없이 primary 에서 읽는다.
+func (s *AuthService) ListUsers(ctx context.Context, afterID int64, limit int) ([]model.User, error) {
+	return s.userRepo.ListUsers(ctx, s.db, afterID, limit)
+}
+
+// SetRole 역할을 바꾸고 이전 역할이 담긴 토큰의 세션을 폐기한다.
+// 게스트는 이메일이 없으므로 UpgradeGuest 로만 역할이 바뀐다.
+func (s *AuthService) SetRole(ctx context.Context, id int64, role string) error {
+	if !model.ValidRole(role) {
+		return fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == model.RoleGuest {
+		return fmt.Errorf("%w: guest must be upgraded first", ErrInvalidRole)
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	user.Role = role
+	return s.revokeWith(ctx, user, func(tx mysqlconn.Tx) error {
+		return s.userRepo.UpdateRole(ctx, tx, user)
+	})
+}
+
+// DisableUser 로그인을 막고 현재 세션을 폐기한다. 이미 비활성화된 계정은 그대로 둔다.
+func (s *AuthService) DisableUser(ctx context.Context, id int64) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	now := s.clock.Now()
+	user.DisabledAt = &now
+	return s.revokeWith(ctx, user, func(tx mysqlconn.Tx) error {
+		return s.userRepo.DisableUser(ctx, tx, user)
+	})
+}
+
+// IssueToken 비밀번호 없이 토큰을 발급한다. 로그인과 같은 세션을 저장하지만 last_login 은 바꾸지 않는다.
+func (s *AuthService) IssueToken(ctx context.Context, id int64) (string, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if user.DisabledAt != nil {
+		return "", ErrUserDisabled
+	}
+
+	var token string
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		issued, err := s.issueToken(ctx, tx, user, s.clock.Now())
+		token = issued
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	s.notifier.Notify()
+
+	s.metrics.IncTokensIssued()
+	return token, nil
+}
+
+// RevokeUserToken 이메일이 없는 게스트도 폐기할 수 있도록 id 로 세션을 찾는다.
+func (s *AuthService) RevokeUserToken(ctx context.Context, id int64) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.revokeWith(ctx, user, func(mysqlconn.Tx) error {
+		return nil
+	})
+}
+
+// revokeWith update 와 세션 폐기를 한 트랜잭션에 기록한다.
+func (s *AuthService) revokeWith(ctx context.Context, user *model.User, update func(tx mysqlconn.Tx) error) error {
+	err := mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		if err := update(tx); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, tx, model.OutboxSessionDelete, model.SessionPayload{Key: sessionKey(user)})
+	})
+	if err != nil {
+		return err
+	}
+	s.notifier.Notify()
+
+	s.metrics.IncTokensRevoked()
+	return nil
+}
+
+func (s *AuthService) getUser(ctx context.Context, id int64) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, s.db, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrUserNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
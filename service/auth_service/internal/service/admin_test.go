@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"auth_service/internal/model"
+	"pkg/auth"
+	"pkg/clock"
+)
+
+func TestCreateUser(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "admin@example.com"
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("CreateUser", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.Email == email && u.Role == model.RoleUser && u.CreatedAt.Equal(testNow)
+		})).
+		Run(func(args mock.Arguments) {
+			args.Get(2).(*model.User).ID = 1
+		}).
+		Return(nil)
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxUserRegistered, model.UserEventPayload{UserID: 1, Role: model.RoleUser})).
+		Return(nil)
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	_, err = service.CreateUser(ctx, email, "password123", model.RoleGuest)
+	assert.ErrorIs(t, err, ErrInvalidRole)
+
+	user, err := service.CreateUser(ctx, email, "password123", model.RoleUser)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), user.ID)
+
+	mockUserRepo.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSetRole(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	ctx := context.Background()
+	user := &model.User{ID: 1, Email: "test@example.com", Role: model.RoleAdmin}
+	guest := &model.User{ID: 2, Role: model.RoleGuest}
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", ctx, xdb, user.ID).Return(user, nil)
+	mockUserRepo.On("GetUserByID", ctx, xdb, guest.ID).Return(guest, nil)
+	mockUserRepo.On("GetUserByID", ctx, xdb, int64(3)).Return(nil, sql.ErrNoRows)
+	mockUserRepo.
+		On("UpdateRole", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.ID == user.ID && u.Role == model.RoleUser
+		})).
+		Return(nil).
+		Once()
+
+	// 토큰의 role 클레임이 바뀌므로 세션을 폐기한다.
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionDelete, model.SessionPayload{Key: GetTokenKey(user.Email)})).
+		Return(nil).
+		Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	assert.NoError(t, service.SetRole(ctx, user.ID, model.RoleUser))
+	// 같은 역할이면 아무것도 하지 않는다.
+	assert.NoError(t, service.SetRole(ctx, user.ID, model.RoleUser))
+	assert.ErrorIs(t, service.SetRole(ctx, guest.ID, model.RoleAdmin), ErrInvalidRole)
+	assert.ErrorIs(t, service.SetRole(ctx, 3, model.RoleAdmin), ErrUserNotFound)
+
+	mockUserRepo.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestDisableUser(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	ctx := context.Background()
+	guest := newGuest(t, 7, "device-1", "secret")
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", ctx, mock.Anything, guest.ID).Return(guest, nil)
+	mockUserRepo.
+		On("DisableUser", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.DisabledAt != nil && u.DisabledAt.Equal(testNow)
+		})).
+		Return(nil).
+		Once()
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionDelete, model.SessionPayload{Key: GetGuestTokenKey(guest.ID)})).
+		Return(nil).
+		Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	assert.NoError(t, service.DisableUser(ctx, guest.ID))
+	assert.NoError(t, service.DisableUser(ctx, guest.ID))
+
+	// 비활성화된 계정은 로그인할 수 없고 토큰도 발급하지 않는다.
+	_, err = service.LoginGuest(ctx, guest.ID, "device-1", "secret")
+	assert.EqualError(t, err, "invalid guest credential")
+	_, err = service.IssueToken(ctx, guest.ID)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+
+	mockUserRepo.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestIssueToken(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	ctx := context.Background()
+	user := &model.User{ID: 1, Email: "test@example.com", Role: model.RoleAdmin}
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", ctx, xdb, user.ID).Return(user, nil)
+	mockJWTGenerator := new(MockJWTGenerator)
+	mockJWTGenerator.
+		On("GenerateToken", ctx, auth.User{ID: user.ID, Email: user.Email, Role: user.Role}).
+		Return("jwt-token-value", nil)
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionSave, model.SessionPayload{
+			Key:      GetTokenKey(user.Email),
+			Token:    "jwt-token-value",
+			ExpireAt: testNow.Add(defaultSessionExpire),
+		})).
+		Return(nil)
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
+	token, err := service.IssueToken(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "jwt-token-value", token)
+
+	mockUserRepo.AssertExpectations(t)
+	mockJWTGenerator.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -5,130 +5,340 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	"golang.org/x/crypto/bcrypt"
 
 	"auth_service/internal/model"
+	"auth_service/internal/repository"
 	"pkg/auth"
+	"pkg/clock"
+	"pkg/logger"
+	"pkg/mysqlconn"
 )
 
 const tokenSize = 32
-const sessionExpire = 24 * time.Hour
+const defaultSessionExpire = 24 * time.Hour
+
+// ErrInvalidCredential 이메일이 없는 경우와 비밀번호가 틀린 경우를 구분하지 않는다.
+var ErrInvalidCredential = errors.New("invalid email or password")
+
+var ErrUserExists = errors.New("user already exists")
+
+// 로그인 결과 지표 라벨
+const (
+	LoginSuccess           = "success"
+	LoginInvalidCredential = "invalid_credential"
+	LoginError             = "error"
+)
 
 type AuthService struct {
-	db          *sqlx.DB
-	userRepo    UserRepository
-	redisClient RedisClient
-	jwtGen      JWTGenerator
+	// 트랜잭션과 복제 지연이 없어야 하는 조회는 db 를 직접 사용한다.
+	db       *sqlx.DB
+	router   DBRouter
+	userRepo UserRepository
+	outbox   OutboxRepository
+	notifier Notifier
+	jwtGen   JWTGenerator
+	hasher   PasswordHasher
+	clock    clock.Clock
+	// Redis 에 저장하는 세션의 유지 시간
+	sessionExpire time.Duration
+
+	// 가입 여부를 응답으로 드러내지 않고 mailer 로 결과를 알린다.
+	concealRegistration bool
+	mailer              Mailer
+
+	metrics Metrics
+
+	dummyHashOnce sync.Once
+	dummyHash     string
+}
+
+type Option func(*AuthService)
+
+// WithOutboxNotifier 커밋한 뒤 relay 를 깨워 세션 저장이 늦어지지 않게 한다.
+func WithOutboxNotifier(n Notifier) Option {
+	return func(s *AuthService) {
+		s.notifier = n
+	}
+}
+
+// WithDBRouter 로그인 조회를 복제본으로 보낸다. 없으면 모두 db 를 사용한다.
+func WithDBRouter(r DBRouter) Option {
+	return func(s *AuthService) {
+		s.router = r
+	}
+}
+
+func WithMetrics(m Metrics) Option {
+	return func(s *AuthService) {
+		s.metrics = m
+	}
+}
+
+// WithClock created_at, last_login 과 세션 만료 시각에 사용할 시계. JWTGenerator 와 같은 시계를 사용해야 iat, exp 와 일치한다.
+func WithClock(c clock.Clock) Option {
+	return func(s *AuthService) {
+		s.clock = c
+	}
+}
+
+// WithSessionExpire 세션 유지 시간. 토큰 만료 시간과 같게 설정한다.
+func WithSessionExpire(d time.Duration) Option {
+	return func(s *AuthService) {
+		s.sessionExpire = d
+	}
 }
 
-func NewAuthService(db *sqlx.DB, ur UserRepository, rc RedisClient, jwtGen JWTGenerator) *AuthService {
-	return &AuthService{
-		db:          db,
-		userRepo:    ur,
-		redisClient: rc,
-		jwtGen:      jwtGen,
+// WithConcealedRegistration 이미 가입된 이메일에도 성공과 같은 응답을 주고 실제 결과는 m 으로 보낸다.
+func WithConcealedRegistration(m Mailer) Option {
+	return func(s *AuthService) {
+		s.concealRegistration = true
+		s.mailer = m
 	}
 }
 
+func NewAuthService(db *sqlx.DB, ur UserRepository, ob OutboxRepository, jwtGen JWTGenerator, hasher PasswordHasher, opts ...Option) *AuthService {
+	s := &AuthService{
+		db:            db,
+		router:        primaryRouter{db: db},
+		userRepo:      ur,
+		outbox:        ob,
+		notifier:      nopNotifier{},
+		jwtGen:        jwtGen,
+		hasher:        hasher,
+		clock:         clock.Real{},
+		sessionExpire: defaultSessionExpire,
+		metrics:       nopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 // GetTokenKey returns Redis key for JWT token
 func GetTokenKey(email string) string {
 	return fmt.Sprintf("jwt:%s", email)
 }
 
 func (s *AuthService) RegisterUser(ctx context.Context, email, password string) error {
+	// 중복 확인은 최적화일 뿐이고 동시 가입은 CreateUser 의 유니크 제약 조건으로 막는다.
 	existing, _ := s.userRepo.GetUserByEmail(ctx, s.db, email)
 	if existing != nil {
-		return fmt.Errorf("user already exists")
+		// 응답 시간도 같도록 해시를 만들고 버린다.
+		_, _ = s.hasher.Hash(password)
+		return s.userExists(ctx, email)
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := s.hasher.Hash(password)
 	if err != nil {
 		return err
 	}
 
-	now := ctx.Value("time" /* TODO ctx key package*/).(time.Time)
-	if now == (time.Time{}) {
-		now = time.Now()
-	}
+	now := s.clock.Now()
 
+	// 관리자는 가입으로 만들지 않고 CLI 의 user set-role 로 지정한다.
 	user := &model.User{
 		Email:     email,
-		Password:  string(hash),
-		Role:      "admin", // TODO: role
+		Password:  hash,
+		Role:      model.RoleUser,
 		CreatedAt: now,
 		LastLogin: now,
 	}
-	if err = s.userRepo.CreateUser(ctx, s.db, user); err != nil {
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		if err := s.userRepo.CreateUser(ctx, tx, user); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, tx, model.OutboxUserRegistered, model.UserEventPayload{UserID: user.ID, Role: user.Role})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return s.userExists(ctx, email)
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
+	s.notifier.Notify()
+	s.metrics.IncRegistrations()
+	logger.FromContext(ctx).Info("user registered", "user_id", user.ID)
 
+	if s.concealRegistration {
+		s.notify(ctx, email, s.mailer.SendRegistered)
+	}
 	return nil
 }
 
-func (s *AuthService) LoginUser(ctx context.Context, email, password string) (string, error) {
-	user, err := s.userRepo.GetUserByEmail(ctx, s.db, email)
-	if err != nil {
-		return "", fmt.Errorf("user not found: %w", err)
+// ConcealsRegistration 가입 결과를 응답 대신 메일로 알리는지 반환한다.
+func (s *AuthService) ConcealsRegistration() bool {
+	return s.concealRegistration
+}
+
+func (s *AuthService) userExists(ctx context.Context, email string) error {
+	if !s.concealRegistration {
+		return ErrUserExists
 	}
+	s.notify(ctx, email, s.mailer.SendAlreadyRegistered)
+	return nil
+}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", fmt.Errorf("invalid password")
+func (s *AuthService) notify(ctx context.Context, email string, send func(context.Context, string) error) {
+	if err := send(ctx, email); err != nil {
+		logger.FromContext(ctx).Error("failed to send registration mail", "error", err)
 	}
+}
 
-	tx, err := s.db.Begin()
+func (s *AuthService) LoginUser(ctx context.Context, email, password string) (string, error) {
+	token, err := s.loginUser(ctx, email, password)
+	switch {
+	case err == nil:
+		s.metrics.IncLogins(LoginSuccess)
+	case errors.Is(err, ErrInvalidCredential):
+		s.metrics.IncLogins(LoginInvalidCredential)
+	default:
+		s.metrics.IncLogins(LoginError)
+	}
+	return token, err
+}
+
+func (s *AuthService) loginUser(ctx context.Context, email, password string) (string, error) {
+	user, err := s.readUser(ctx, func(q repository.Queryer) (*model.User, error) {
+		return s.userRepo.GetUserByEmail(ctx, q, email)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// 없는 이메일도 해시 비교를 거쳐 응답 시간으로 가입 여부를 알 수 없게 한다.
+		_, _ = s.hasher.Verify(s.getDummyHash(), password)
+		return "", ErrInvalidCredential
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to begin transaction: %w", err)
+		return "", fmt.Errorf("failed to get user: %w", err)
 	}
-	defer func(tx *sql.Tx) {
-		_ = tx.Rollback()
-	}(tx)
 
-	now := ctx.Value("time" /* TODO ctx key package*/).(time.Time)
-	if now == (time.Time{}) {
-		now = time.Now()
+	l := logger.FromContext(ctx).With("user_id", user.ID)
+	if ok, err := s.hasher.Verify(user.Password, password); err != nil || !ok {
+		l.Info("login failed", "reason", "invalid password")
+		return "", ErrInvalidCredential
+	}
+	if user.DisabledAt != nil {
+		l.Info("login failed", "reason", "disabled")
+		return "", ErrInvalidCredential
 	}
 
-	user.LastLogin = now
-	if err = s.userRepo.UpdateLastLogin(ctx, tx, user); err != nil {
-		return "", fmt.Errorf("failed to update last login: %w", err)
+	now := s.clock.Now()
+
+	// 이전 알고리즘이나 파라미터로 만든 해시는 비밀번호를 알고 있는 지금 갱신한다.
+	// 트랜잭션을 다시 시도해도 같은 해시를 쓰도록 밖에서 한 번만 만든다.
+	var rehashed string
+	if s.hasher.NeedsRehash(user.Password) {
+		rehashed = s.rehash(l, password)
 	}
 
-	// JWT 토큰 생성
-	tokenUser := auth.User{
+	// 세션은 last_login 과 같은 트랜잭션으로 outbox 에 기록해서 커밋된 로그인만 Redis 에 반영된다.
+	var token string
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		user.LastLogin = now
+		if err := s.userRepo.UpdateLastLogin(ctx, tx, user); err != nil {
+			return fmt.Errorf("failed to update last login: %w", err)
+		}
+		if rehashed != "" {
+			if err := s.updatePassword(ctx, l, tx, user, rehashed); err != nil {
+				return err
+			}
+		}
+
+		issued, err := s.issueToken(ctx, tx, user, now)
+		token = issued
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	s.notifier.Notify()
+
+	s.metrics.IncTokensIssued()
+	l.Info("user logged in")
+	return token, nil
+}
+
+// issueToken 토큰을 만들고 exec 의 트랜잭션에 세션 저장을 기록한다.
+func (s *AuthService) issueToken(ctx context.Context, exec repository.Execer, user *model.User, now time.Time) (string, error) {
+	token, err := s.jwtGen.GenerateToken(ctx, auth.User{
 		ID:    user.ID,
 		Email: user.Email,
 		Role:  user.Role,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	token, err := s.jwtGen.GenerateToken(ctx, tokenUser)
+	err = s.enqueue(ctx, exec, model.OutboxSessionSave, model.SessionPayload{
+		Key:      sessionKey(user),
+		Token:    token,
+		ExpireAt: now.Add(s.sessionExpire),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return "", err
+	}
+	return token, nil
+}
+
+// sessionKey 게스트는 이메일이 없으므로 id 로 세션을 찾는다.
+func sessionKey(user *model.User) string {
+	if user.Role == model.RoleGuest {
+		return GetGuestTokenKey(user.ID)
 	}
+	return GetTokenKey(user.Email)
+}
 
-	// Redis에 토큰 저장
-	if err := s.redisClient.Save(
-		ctx,
-		GetTokenKey(email),
-		token,
-		sessionExpire,
-	); err != nil {
-		return "", fmt.Errorf("failed to store token: %w", err)
+// readUser 복제본에 아직 반영되지 않은 방금 만든 계정은 primary 에서 다시 찾는다.
+func (s *AuthService) readUser(ctx context.Context, get func(repository.Queryer) (*model.User, error)) (*model.User, error) {
+	q := s.router.Reader(ctx)
+	user, err := get(q)
+	if errors.Is(err, sql.ErrNoRows) && q != repository.Queryer(s.db) {
+		return get(s.db)
 	}
+	return user, err
+}
 
-	if err = tx.Commit(); err != nil {
-		// 트랜잭션 실패 시 토큰 삭제
-		_ = s.redisClient.Delete(ctx, GetTokenKey(email))
-		return "", fmt.Errorf("failed to commit transaction: %w", err)
+// getDummyHash 현재 설정으로 만든 해시를 돌려준다. 설정과 같은 비용으로 비교하기 위해 처음 사용할 때 만든다.
+func (s *AuthService) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash(generateToken())
+	})
+	return s.dummyHash
+}
+
+// rehash 실패해도 기존 해시로 로그인할 수 있으므로 로그만 남기고 빈 문자열을 돌려준다.
+func (s *AuthService) rehash(l *slog.Logger, password string) string {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		l.Error("failed to rehash password", "error", err)
+		return ""
 	}
+	return hash
+}
 
-	return token, nil
+// updatePassword user 는 바꾸지 않고 복사본으로 해시를 저장한다.
+// 해시 갱신이 실패해도 로그인은 계속하지만, 교착 상태로 트랜잭션이 롤백된 경우는 다시 시도하도록 반환한다.
+func (s *AuthService) updatePassword(ctx context.Context, l *slog.Logger, exec repository.Execer, user *model.User, hash string) error {
+	rehashed := *user
+	rehashed.Password = hash
+	if err := s.userRepo.UpdatePassword(ctx, exec, &rehashed); err != nil {
+		if mysqlconn.Retryable(err) {
+			return fmt.Errorf("failed to update password hash: %w", err)
+		}
+		l.Error("failed to update password hash", "error", err)
+		return nil
+	}
+	l.Info("password rehashed")
+	return nil
 }
 
-// 이 함수는 후방 호환성을 위해 남겨두지만 더 이상 사용되지 않습니다.
+// 게스트 시크릿 생성에 사용한다.
 func generateToken() string {
 	b := make([]byte, tokenSize)
 	if _, err := rand.Read(b); err != nil {
@@ -138,7 +348,48 @@ func generateToken() string {
 	return base64.StdEncoding.EncodeToString(b)
 }
 
-// 토큰 폐기
+// 토큰 폐기. relay 는 기록 순서대로 적용하므로 outbox 를 거치면 먼저 기록된 세션 저장보다 늦게 적용된다.
 func (s *AuthService) RevokeToken(ctx context.Context, email string) error {
-	return s.redisClient.Delete(ctx, GetTokenKey(email))
+	if err := s.enqueue(ctx, s.router.Writer(ctx), model.OutboxSessionDelete, model.SessionPayload{Key: GetTokenKey(email)}); err != nil {
+		return err
+	}
+	s.notifier.Notify()
+	s.metrics.IncTokensRevoked()
+	return nil
+}
+
+// enqueue exec 의 트랜잭션에 부수 효과를 기록한다. 커밋한 뒤 notifier 에 알린다.
+func (s *AuthService) enqueue(ctx context.Context, exec repository.Execer, kind string, payload any) error {
+	msg, err := model.NewOutboxMessage(kind, payload, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	if err = s.outbox.Add(ctx, exec, msg); err != nil {
+		return fmt.Errorf("failed to add outbox message: %w", err)
+	}
+	return nil
+}
+
+type nopNotifier struct{}
+
+func (nopNotifier) Notify() {}
+
+type nopMetrics struct{}
+
+func (nopMetrics) IncRegistrations()        {}
+func (nopMetrics) IncLogins(outcome string) {}
+func (nopMetrics) IncTokensIssued()         {}
+func (nopMetrics) IncTokensRevoked()        {}
+
+// primaryRouter 복제본 없이 모든 쿼리를 db 로 보낸다.
+type primaryRouter struct {
+	db *sqlx.DB
+}
+
+func (r primaryRouter) Reader(context.Context) mysqlconn.Queryer {
+	return r.db
+}
+
+func (r primaryRouter) Writer(context.Context) mysqlconn.Execer {
+	return r.db
 }
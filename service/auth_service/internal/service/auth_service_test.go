@@ -3,18 +3,31 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"pkg/auth"
+	"pkg/clock"
+	"pkg/mysqlconn"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 
+	"auth_service/internal/metrics"
 	"auth_service/internal/model"
+	"auth_service/internal/password"
 	"auth_service/internal/repository"
 )
 
@@ -39,20 +52,69 @@ func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, exec repositor
 	return args.Error(0)
 }
 
-type MockRedisClient struct {
-	mock.Mock
+func (m *MockUserRepository) CreateGuest(ctx context.Context, exec repository.Execer, user *model.User) error {
+	args := m.Called(ctx, exec, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetUserByID(ctx context.Context, q repository.Queryer, id int64) (*model.User, error) {
+	args := m.Called(ctx, q, id)
+	if u := args.Get(0); u != nil {
+		return u.(*model.User), args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
-func (m *MockRedisClient) Save(ctx context.Context, key, token string, expire time.Duration) error {
-	args := m.Called(ctx, key, token, expire)
+func (m *MockUserRepository) UpgradeGuest(ctx context.Context, exec repository.Execer, user *model.User) error {
+	args := m.Called(ctx, exec, user)
 	return args.Error(0)
 }
 
-func (m *MockRedisClient) Delete(ctx context.Context, key string) error {
-	args := m.Called(ctx, key)
+func (m *MockUserRepository) DeleteStaleGuests(ctx context.Context, exec repository.Execer, before time.Time) (int64, error) {
+	args := m.Called(ctx, exec, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, exec repository.Execer, user *model.User) error {
+	args := m.Called(ctx, exec, user)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ListUsers(ctx context.Context, q repository.Queryer, afterID int64, limit int) ([]model.User, error) {
+	args := m.Called(ctx, q, afterID, limit)
+	if u := args.Get(0); u != nil {
+		return u.([]model.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, exec repository.Execer, user *model.User) error {
+	args := m.Called(ctx, exec, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DisableUser(ctx context.Context, exec repository.Execer, user *model.User) error {
+	args := m.Called(ctx, exec, user)
+	return args.Error(0)
+}
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Add(ctx context.Context, exec repository.Execer, msg *model.OutboxMessage) error {
+	args := m.Called(ctx, exec, msg)
+	return args.Error(0)
+}
+
+// outboxMessage kind 와 payload 가 같은 메시지에 맞는 matcher 를 만든다.
+func outboxMessage(kind string, payload any) any {
+	return mock.MatchedBy(func(msg *model.OutboxMessage) bool {
+		expected, err := json.Marshal(payload)
+		return err == nil && msg.Kind == kind && string(msg.Payload) == string(expected)
+	})
+}
+
 type MockJWTGenerator struct {
 	mock.Mock
 }
@@ -67,8 +129,31 @@ func (m *MockJWTGenerator) RevokeToken(ctx context.Context, email string) error
 	return args.Error(0)
 }
 
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) SendRegistered(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockMailer) SendAlreadyRegistered(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+var testNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newHasher(t *testing.T) *password.Hasher {
+	t.Helper()
+	h, err := password.New(password.Config{Algorithm: password.Bcrypt, BcryptCost: bcrypt.DefaultCost})
+	assert.NoError(t, err)
+	return h
+}
+
 func TestRegisterUser_Success(t *testing.T) {
-	db, _, err := sqlmock.New()
+	db, mockDB, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer func(db *sql.DB) {
 		_ = db.Close()
@@ -78,7 +163,7 @@ func TestRegisterUser_Success(t *testing.T) {
 	email := "test@example.com"
 	password := "password123"
 
-	ctx := context.WithValue(context.Background(), "time", time.Now())
+	ctx := context.Background()
 
 	mockRepo := new(MockUserRepository)
 	mockRepo.
@@ -86,13 +171,29 @@ func TestRegisterUser_Success(t *testing.T) {
 		Return(nil, nil)
 
 	mockRepo.
-		On("CreateUser", ctx, xdb, mock.AnythingOfType("*model.User")).
+		On("CreateUser", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.Role == model.RoleUser && u.CreatedAt.Equal(testNow) && u.LastLogin.Equal(testNow)
+		})).
+		Run(func(args mock.Arguments) {
+			args.Get(2).(*model.User).ID = 1
+		}).
 		Return(nil)
 
-	service := NewAuthService(xdb, mockRepo, nil, nil)
+	// 가입 이벤트는 계정과 같은 트랜잭션에 기록한다.
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxUserRegistered, model.UserEventPayload{UserID: 1, Role: model.RoleUser})).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockRepo, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
 	err = service.RegisterUser(ctx, email, password)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
 }
 
 func TestRegisterUser_UserExists(t *testing.T) {
@@ -105,7 +206,7 @@ func TestRegisterUser_UserExists(t *testing.T) {
 
 	email := "test@example.com"
 	password := "password123"
-	existingUser := &model.User{ID: 1, Email: email, Password: password, Role: "admin" /*TODO:role*/}
+	existingUser := &model.User{ID: 1, Email: email, Password: password, Role: model.RoleUser}
 
 	ctx := context.Background()
 
@@ -114,13 +215,191 @@ func TestRegisterUser_UserExists(t *testing.T) {
 		On("GetUserByEmail", ctx, xdb, email).
 		Return(existingUser, nil)
 
-	service := NewAuthService(xdb, mockUserRepo, nil, nil)
+	service := NewAuthService(xdb, mockUserRepo, nil, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
 	err = service.RegisterUser(ctx, email, password)
 	assert.Error(t, err)
 	assert.Equal(t, "user already exists", err.Error())
 	mockUserRepo.AssertExpectations(t)
 }
 
+func TestRegisterUser_Concurrent(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	password := "password123"
+	ctx := context.Background()
+
+	// 두 요청 모두 중복 확인을 통과하고 INSERT 에서 하나만 성공한다.
+	mockDB.MatchExpectationsInOrder(false)
+	for range 2 {
+		mockDB.ExpectQuery(`SELECT .+ FROM account WHERE email = \?`).
+			WithArgs(email).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+	for range 2 {
+		mockDB.ExpectBegin()
+	}
+	mockDB.ExpectExec(`INSERT INTO account`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`INSERT INTO account`).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mockDB.ExpectExec(`INSERT INTO outbox`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectCommit()
+	mockDB.ExpectRollback()
+
+	service := NewAuthService(xdb, repository.NewUserRepository(), repository.NewOutboxRepository(), nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	errs := registerConcurrently(ctx, service, email, password, 2)
+
+	assert.ElementsMatch(t, []error{nil, ErrUserExists}, errs)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRegisterUser_ConcurrentRealDB(t *testing.T) {
+	mc, err := mysqlconn.New(
+		getEnv("DB_USER", "auth"),
+		getEnv("DB_PASSWORD", "1234"),
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", "3306"),
+		getEnv("DB_NAME", "auth"),
+		// DB 가 없으면 오래 기다리지 않고 건너뛴다.
+		mysqlconn.WithConnectRetry(time.Second, 100*time.Millisecond, 100*time.Millisecond),
+	)
+	if err != nil {
+		t.Skipf("mysql is not available: %v", err)
+	}
+	t.Cleanup(func() { _ = mc.Close() })
+
+	email := fmt.Sprintf("race-%d@example.com", time.Now().UnixNano())
+	password := "password123"
+	ctx := context.Background()
+	t.Cleanup(func() {
+		_, _ = mc.Conn().Exec("DELETE FROM account WHERE email = ?", email)
+	})
+
+	const n = 8
+	service := NewAuthService(mc.Conn(), repository.NewUserRepository(), repository.NewOutboxRepository(), nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	errs := registerConcurrently(ctx, service, email, password, n)
+
+	var created int
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrUserExists)
+	}
+	assert.Equal(t, 1, created)
+}
+
+func registerConcurrently(ctx context.Context, s *AuthService, email, password string, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.RegisterUser(ctx, email, password)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func TestRegisterUser_Concealed(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	newEmail := "new@example.com"
+	existingEmail := "test@example.com"
+	password := "password123"
+	existingUser := &model.User{ID: 1, Email: existingEmail, Password: password, Role: model.RoleUser}
+
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, existingEmail).
+		Return(existingUser, nil)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, newEmail).
+		Return(nil, sql.ErrNoRows)
+	mockUserRepo.
+		On("CreateUser", ctx, mock.Anything, mock.AnythingOfType("*model.User")).
+		Return(nil)
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, mock.Anything).
+		Return(nil)
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	mockMailer := new(MockMailer)
+	mockMailer.
+		On("SendAlreadyRegistered", ctx, existingEmail).
+		Return(nil)
+	mockMailer.
+		On("SendRegistered", ctx, newEmail).
+		Return(nil)
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)), WithConcealedRegistration(mockMailer))
+	assert.NoError(t, service.RegisterUser(ctx, existingEmail, password))
+	assert.NoError(t, service.RegisterUser(ctx, newEmail, password))
+
+	mockUserRepo.AssertExpectations(t)
+	mockUserRepo.AssertNumberOfCalls(t, "CreateUser", 1)
+	mockOutbox.AssertNumberOfCalls(t, "Add", 1)
+	mockMailer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRegisterUser_Deadlock(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	ctx := context.Background()
+
+	// 교착 상태로 롤백된 INSERT 는 트랜잭션을 다시 시작해서 재시도한다.
+	mockDB.ExpectQuery(`SELECT .+ FROM account WHERE email = \?`).
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`INSERT INTO account`).
+		WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+	mockDB.ExpectRollback()
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`INSERT INTO account`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`INSERT INTO outbox`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, repository.NewUserRepository(), repository.NewOutboxRepository(), nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	assert.NoError(t, service.RegisterUser(ctx, email, "password123"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
 func TestLoginUser_Success(t *testing.T) {
 	db, mockDB, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -144,7 +423,7 @@ func TestLoginUser_Success(t *testing.T) {
 		LastLogin: time.Now(),
 	}
 
-	ctx := context.WithValue(context.Background(), "time", time.Now())
+	ctx := context.Background()
 
 	mockUserRepo := new(MockUserRepository)
 	mockUserRepo.
@@ -163,25 +442,281 @@ func TestLoginUser_Success(t *testing.T) {
 		})).
 		Return(expectedToken, nil)
 
-	mockRedisClient := new(MockRedisClient)
-	mockRedisClient.
-		On("Save", ctx, GetTokenKey(email), expectedToken, sessionExpire).
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionSave, model.SessionPayload{
+			Key:      GetTokenKey(email),
+			Token:    expectedToken,
+			ExpireAt: testNow.Add(defaultSessionExpire),
+		})).
 		Return(nil)
 
 	mockDB.ExpectBegin()
 	mockDB.ExpectCommit()
 
-	service := NewAuthService(xdb, mockUserRepo, mockRedisClient, mockJWTGenerator)
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
 	token, err := service.LoginUser(ctx, email, password)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedToken, token)
+	assert.Equal(t, testNow, user.LastLogin)
 
 	mockUserRepo.AssertExpectations(t)
 	mockJWTGenerator.AssertExpectations(t)
-	mockRedisClient.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestLoginUser_ClockConsistency(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	password := "password123"
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	assert.NoError(t, err)
+	user := &model.User{ID: 1, Email: email, Password: string(hash), Role: "admin" /*TODO:role*/}
+
+	ctx := context.Background()
+	fake := clock.NewFake(testNow)
+	jwtManager, err := auth.NewJWTManager(auth.JWTConfig{
+		Issuer:     "test",
+		ExpiresIn:  defaultSessionExpire,
+		SignMethod: jwa.RS256,
+		Clock:      fake,
+	})
+	assert.NoError(t, err)
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, email).
+		Return(user, nil)
+	mockUserRepo.
+		On("UpdateLastLogin", ctx, mock.Anything, user).
+		Return(nil)
+
+	var session model.SessionPayload
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.NoError(t, json.Unmarshal(args.Get(2).(*model.OutboxMessage).Payload, &session))
+		}).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, jwtManager, newHasher(t), WithClock(fake))
+	token, err := service.LoginUser(ctx, email, password)
+	assert.NoError(t, err)
+
+	// 토큰의 iat, exp 와 last_login, 세션 만료 시각이 같은 시계를 따른다.
+	tok, err := jwtManager.VerifyToken(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, testNow, user.LastLogin)
+	assert.True(t, tok.IssuedAt().Equal(user.LastLogin))
+	assert.True(t, tok.Expiration().Equal(testNow.Add(defaultSessionExpire)))
+	assert.True(t, session.ExpireAt.Equal(tok.Expiration()))
+
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestLoginUser_Rehash(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	password := "password123"
+
+	// 현재 설정보다 낮은 cost 로 만든 해시
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	user := &model.User{
+		ID:       1,
+		Email:    email,
+		Password: string(hash),
+		Role:     "admin", // TODO: role
+	}
+
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, email).
+		Return(user, nil)
+	mockUserRepo.
+		On("UpdateLastLogin", ctx, mock.Anything, user).
+		Return(nil)
+	mockUserRepo.
+		On("UpdatePassword", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			cost, err := bcrypt.Cost([]byte(u.Password))
+			return err == nil && cost == bcrypt.DefaultCost
+		})).
+		Return(nil)
+
+	mockJWTGenerator := new(MockJWTGenerator)
+	mockJWTGenerator.
+		On("GenerateToken", ctx, mock.Anything).
+		Return("jwt-token-value", nil)
+
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, mock.Anything).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
+	_, err = service.LoginUser(ctx, email, password)
+	assert.NoError(t, err)
+
+	mockUserRepo.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestLoginUser_RehashDeadlock(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	password := "password123"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &model.User{ID: 1, Email: email, Password: string(hash), Role: model.RoleUser}
+
+	ctx := context.Background()
+
+	// 교착 상태로 롤백되면 다시 시도하는 트랜잭션에도 같은 해시를 저장한다.
+	var saved []string
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, email).
+		Return(user, nil)
+	mockUserRepo.
+		On("UpdateLastLogin", ctx, mock.Anything, user).
+		Return(nil)
+	mockUserRepo.
+		On("UpdatePassword", ctx, mock.Anything, mock.AnythingOfType("*model.User")).
+		Run(func(args mock.Arguments) {
+			saved = append(saved, args.Get(2).(*model.User).Password)
+		}).
+		Return(nil)
+
+	mockJWTGenerator := new(MockJWTGenerator)
+	mockJWTGenerator.
+		On("GenerateToken", ctx, mock.Anything).
+		Return("jwt-token-value", nil)
+
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, mock.Anything).
+		Return(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}).
+		Once()
+	mockOutbox.
+		On("Add", ctx, mock.Anything, mock.Anything).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
+	_, err = service.LoginUser(ctx, email, password)
+	assert.NoError(t, err)
+
+	assert.Len(t, saved, 2)
+	assert.Equal(t, saved[0], saved[1])
+	assert.Equal(t, string(hash), user.Password)
 	assert.NoError(t, mockDB.ExpectationsWereMet())
 }
 
+func TestLoginUser_RehashUpdateError(t *testing.T) {
+	tests := map[string]struct {
+		err   error
+		calls int
+	}{
+		// 롤백된 트랜잭션은 이어서 쓸 수 없으므로 트랜잭션째 다시 시도한다.
+		"deadlock": {err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, calls: 2},
+		// 그 밖의 오류는 해시를 갱신하지 않고 로그인한다.
+		"other": {err: errors.New("data too long"), calls: 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, mockDB, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer func(db *sql.DB) {
+				_ = db.Close()
+			}(db)
+			xdb := sqlx.NewDb(db, "mysql")
+
+			email := "test@example.com"
+			password := "password123"
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+			assert.NoError(t, err)
+			user := &model.User{ID: 1, Email: email, Password: string(hash), Role: model.RoleUser}
+
+			ctx := context.Background()
+
+			mockUserRepo := new(MockUserRepository)
+			mockUserRepo.
+				On("GetUserByEmail", ctx, xdb, email).
+				Return(user, nil)
+			mockUserRepo.
+				On("UpdateLastLogin", ctx, mock.Anything, user).
+				Return(nil)
+			mockUserRepo.
+				On("UpdatePassword", ctx, mock.Anything, mock.AnythingOfType("*model.User")).
+				Return(tt.err).
+				Once()
+			mockUserRepo.
+				On("UpdatePassword", ctx, mock.Anything, mock.AnythingOfType("*model.User")).
+				Return(nil)
+
+			mockJWTGenerator := new(MockJWTGenerator)
+			mockJWTGenerator.
+				On("GenerateToken", ctx, mock.Anything).
+				Return("jwt-token-value", nil)
+
+			mockOutbox := new(MockOutboxRepository)
+			mockOutbox.
+				On("Add", ctx, mock.Anything, mock.Anything).
+				Return(nil)
+
+			for range tt.calls - 1 {
+				mockDB.ExpectBegin()
+				mockDB.ExpectRollback()
+			}
+			mockDB.ExpectBegin()
+			mockDB.ExpectCommit()
+
+			service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
+			_, err = service.LoginUser(ctx, email, password)
+			assert.NoError(t, err)
+
+			mockUserRepo.AssertNumberOfCalls(t, "UpdatePassword", tt.calls)
+			mockOutbox.AssertNumberOfCalls(t, "Add", 1)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestLoginUser_InvalidPassword(t *testing.T) {
 	db, _, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -213,15 +748,84 @@ func TestLoginUser_InvalidPassword(t *testing.T) {
 		On("GetUserByEmail", ctx, xdb, email).
 		Return(user, nil)
 
-	service := NewAuthService(xdb, mockUserRepo, nil, nil)
+	service := NewAuthService(xdb, mockUserRepo, nil, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
 	token, err := service.LoginUser(ctx, email, wrongPassword)
-	assert.Error(t, err)
-	assert.Equal(t, "invalid password", err.Error())
+	assert.ErrorIs(t, err, ErrInvalidCredential)
 	assert.Empty(t, token)
 
 	mockUserRepo.AssertExpectations(t)
 }
 
+func TestLoginUser_UserNotFound(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "unknown@example.com"
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, email).
+		Return(nil, sql.ErrNoRows)
+
+	reg := prometheus.NewRegistry()
+	service := NewAuthService(xdb, mockUserRepo, nil, nil, newHasher(t), WithClock(clock.NewFake(testNow)),
+		WithMetrics(metrics.NewAuth(reg)))
+	token, err := service.LoginUser(ctx, email, "password123")
+	assert.ErrorIs(t, err, ErrInvalidCredential)
+	assert.Empty(t, token)
+	// 없는 이메일도 해시 비교를 위한 더미 해시를 사용한다.
+	assert.NotEmpty(t, service.dummyHash)
+
+	expected := `
+# HELP auth_logins_total Number of login attempts by outcome.
+# TYPE auth_logins_total counter
+auth_logins_total{outcome="invalid_credential"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "auth_logins_total"))
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestLoginUser_DBError(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, email).
+		Return(nil, sql.ErrConnDone)
+
+	reg := prometheus.NewRegistry()
+	service := NewAuthService(xdb, mockUserRepo, nil, nil, newHasher(t), WithClock(clock.NewFake(testNow)),
+		WithMetrics(metrics.NewAuth(reg)))
+	token, err := service.LoginUser(ctx, email, "password123")
+	// DB 오류는 잘못된 인증 정보로 숨기지 않는다.
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NotErrorIs(t, err, ErrInvalidCredential)
+	assert.Empty(t, token)
+
+	expected := `
+# HELP auth_logins_total Number of login attempts by outcome.
+# TYPE auth_logins_total counter
+auth_logins_total{outcome="error"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "auth_logins_total"))
+
+	mockUserRepo.AssertExpectations(t)
+}
+
 func TestLoginUser_TransactionFail(t *testing.T) {
 	db, mockDB, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -245,7 +849,7 @@ func TestLoginUser_TransactionFail(t *testing.T) {
 		LastLogin: time.Now(),
 	}
 
-	ctx := context.WithValue(context.Background(), "time", time.Now())
+	ctx := context.Background()
 
 	mockUserRepo := new(MockUserRepository)
 	mockUserRepo.
@@ -264,20 +868,20 @@ func TestLoginUser_TransactionFail(t *testing.T) {
 		})).
 		Return(expectedToken, nil)
 
-	mockRedisClient := new(MockRedisClient)
-	mockRedisClient.
-		On("Save", ctx, GetTokenKey(email), expectedToken, sessionExpire).
-		Return(nil)
-
-	mockRedisClient.
-		On("Delete", ctx, GetTokenKey(email)).
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionSave, model.SessionPayload{
+			Key:      GetTokenKey(email),
+			Token:    expectedToken,
+			ExpireAt: testNow.Add(defaultSessionExpire),
+		})).
 		Return(nil)
 
-	// 트랜잭션 커밋 실패 테스트
+	// 커밋에 실패하면 세션 저장도 함께 롤백되므로 Redis 를 정리할 필요가 없다.
 	mockDB.ExpectBegin()
 	mockDB.ExpectCommit().WillReturnError(fmt.Errorf("commit failed"))
 
-	service := NewAuthService(xdb, mockUserRepo, mockRedisClient, mockJWTGenerator)
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
 	token, err := service.LoginUser(ctx, email, password)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to commit transaction")
@@ -285,7 +889,7 @@ func TestLoginUser_TransactionFail(t *testing.T) {
 
 	mockUserRepo.AssertExpectations(t)
 	mockJWTGenerator.AssertExpectations(t)
-	mockRedisClient.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
 	assert.NoError(t, mockDB.ExpectationsWereMet())
 }
 
@@ -300,14 +904,21 @@ func TestRevokeToken(t *testing.T) {
 	email := "test@example.com"
 	ctx := context.Background()
 
-	mockRedisClient := new(MockRedisClient)
-	mockRedisClient.
-		On("Delete", ctx, GetTokenKey(email)).
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, xdb, outboxMessage(model.OutboxSessionDelete, model.SessionPayload{Key: GetTokenKey(email)})).
 		Return(nil)
 
-	service := NewAuthService(nil, nil, mockRedisClient, nil)
-	err := service.RevokeToken(ctx, email)
+	service := NewAuthService(xdb, nil, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	err = service.RevokeToken(ctx, email)
 	assert.NoError(t, err)
 
-	mockRedisClient.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
 }
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"auth_service/internal/model"
+	"auth_service/internal/repository"
+	"pkg/logger"
+	"pkg/mysqlconn"
+)
+
+// GetGuestTokenKey returns Redis key for guest JWT token
+func GetGuestTokenKey(id int64) string {
+	return fmt.Sprintf("jwt:guest:%d", id)
+}
+
+// RegisterGuest 기기에 묶인 시크릿으로 게스트 계정을 만들고 토큰을 발급한다.
+// 시크릿은 해시만 저장하므로 이 응답에서만 확인할 수 있다.
+func (s *AuthService) RegisterGuest(ctx context.Context, deviceID string) (id int64, secret, token string, err error) {
+	secret = generateToken()
+	if secret == "" {
+		return 0, "", "", fmt.Errorf("failed to generate guest secret")
+	}
+
+	hash, err := s.hasher.Hash(secret)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	now := s.clock.Now()
+
+	user := &model.User{
+		Password:  hash,
+		Role:      model.RoleGuest,
+		DeviceID:  deviceID,
+		CreatedAt: now,
+		LastLogin: now,
+	}
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		if err := s.userRepo.CreateGuest(ctx, tx, user); err != nil {
+			return fmt.Errorf("failed to create guest: %w", err)
+		}
+		if err := s.enqueue(ctx, tx, model.OutboxUserRegistered, model.UserEventPayload{UserID: user.ID, Role: user.Role}); err != nil {
+			return err
+		}
+		issued, err := s.issueToken(ctx, tx, user, now)
+		token = issued
+		return err
+	})
+	if err != nil {
+		return 0, "", "", err
+	}
+	s.notifier.Notify()
+
+	s.metrics.IncRegistrations()
+	s.metrics.IncTokensIssued()
+	return user.ID, secret, token, nil
+}
+
+// LoginGuest 게스트 시크릿을 확인하고 토큰을 다시 발급한다.
+func (s *AuthService) LoginGuest(ctx context.Context, id int64, deviceID, secret string) (string, error) {
+	user, err := s.verifyGuest(ctx, id, deviceID, secret)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.clock.Now()
+
+	var token string
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		user.LastLogin = now
+		if err := s.userRepo.UpdateLastLogin(ctx, tx, user); err != nil {
+			return fmt.Errorf("failed to update last login: %w", err)
+		}
+		issued, err := s.issueToken(ctx, tx, user, now)
+		token = issued
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	s.notifier.Notify()
+
+	s.metrics.IncTokensIssued()
+	return token, nil
+}
+
+// UpgradeGuest 게스트 계정에 이메일과 비밀번호를 연결한다. 계정 ID가 유지되므로 진행 상황도 유지된다.
+// 이미 가입된 이메일은 RegisterUser 와 같이 처리해서 가입 여부를 숨길 수 있다.
+func (s *AuthService) UpgradeGuest(ctx context.Context, id int64, deviceID, secret, email, password string) error {
+	user, err := s.verifyGuest(ctx, id, deviceID, secret)
+	if err != nil {
+		return err
+	}
+
+	existing, _ := s.userRepo.GetUserByEmail(ctx, s.db, email)
+	if existing != nil {
+		_, _ = s.hasher.Hash(password)
+		return s.userExists(ctx, email)
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	user.Email = email
+	user.Password = hash
+	user.Role = model.RoleUser
+	// 게스트 역할로 발급된 토큰은 승격과 함께 폐기한다.
+	err = mysqlconn.WithTx(ctx, s.db, nil, func(tx mysqlconn.Tx) error {
+		if err := s.userRepo.UpgradeGuest(ctx, tx, user); err != nil {
+			return err
+		}
+		if err := s.enqueue(ctx, tx, model.OutboxSessionDelete, model.SessionPayload{Key: GetGuestTokenKey(user.ID)}); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, tx, model.OutboxGuestUpgraded, model.UserEventPayload{UserID: user.ID, Role: user.Role})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return s.userExists(ctx, email)
+		}
+		return fmt.Errorf("failed to upgrade guest: %w", err)
+	}
+
+	s.notifier.Notify()
+
+	s.metrics.IncTokensRevoked()
+	if s.concealRegistration {
+		s.notify(ctx, email, s.mailer.SendRegistered)
+	}
+	return nil
+}
+
+func (s *AuthService) verifyGuest(ctx context.Context, id int64, deviceID, secret string) (*model.User, error) {
+	user, err := s.readUser(ctx, func(q repository.Queryer) (*model.User, error) {
+		return s.userRepo.GetUserByID(ctx, q, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("guest not found: %w", err)
+	}
+
+	if user.Role != model.RoleGuest ||
+		subtle.ConstantTimeCompare([]byte(user.DeviceID), []byte(deviceID)) != 1 {
+		return nil, fmt.Errorf("invalid guest credential")
+	}
+	if ok, err := s.hasher.Verify(user.Password, secret); err != nil || !ok {
+		return nil, fmt.Errorf("invalid guest credential")
+	}
+	if user.DisabledAt != nil {
+		return nil, fmt.Errorf("invalid guest credential")
+	}
+
+	return user, nil
+}
+
+// GuestCleaner 주기적으로 사용되지 않는 게스트 계정을 정리한다.
+type GuestCleaner struct {
+	db       *sqlx.DB
+	userRepo UserRepository
+	interval time.Duration
+	ttl      time.Duration
+}
+
+func NewGuestCleaner(db *sqlx.DB, ur UserRepository, interval, ttl time.Duration) *GuestCleaner {
+	return &GuestCleaner{
+		db:       db,
+		userRepo: ur,
+		interval: interval,
+		ttl:      ttl,
+	}
+}
+
+// Run 컨텍스트가 취소될 때까지 interval 마다 Cleanup 을 실행한다.
+func (c *GuestCleaner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			n, err := c.Cleanup(ctx, now)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				logger.FromContext(ctx).Error("failed to clean up guests", "error", err)
+				continue
+			}
+			if n > 0 {
+				logger.FromContext(ctx).Info("cleaned up guests", "count", n)
+			}
+		}
+	}
+}
+
+// Cleanup now 기준으로 ttl 동안 로그인하지 않은 게스트 계정을 삭제한다.
+func (c *GuestCleaner) Cleanup(ctx context.Context, now time.Time) (int64, error) {
+	return c.userRepo.DeleteStaleGuests(ctx, c.db, now.Add(-c.ttl))
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"auth_service/internal/model"
+	"auth_service/internal/repository"
+	"pkg/auth"
+	"pkg/clock"
+	"pkg/mysqlconn"
+)
+
+func newGuest(t *testing.T, id int64, deviceID, secret string) *model.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return &model.User{
+		ID:       id,
+		Password: string(hash),
+		Role:     model.RoleGuest,
+		DeviceID: deviceID,
+	}
+}
+
+func TestRegisterGuest_Success(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	deviceID := "device-1"
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("CreateGuest", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.Role == model.RoleGuest && u.DeviceID == deviceID && u.Email == ""
+		})).
+		Run(func(args mock.Arguments) {
+			args.Get(2).(*model.User).ID = 7
+		}).
+		Return(nil)
+
+	expectedToken := "jwt-token-value"
+	mockJWTGenerator := new(MockJWTGenerator)
+	mockJWTGenerator.
+		On("GenerateToken", ctx, auth.User{ID: 7, Role: model.RoleGuest}).
+		Return(expectedToken, nil)
+
+	// 계정, 가입 이벤트, 세션을 한 트랜잭션에 기록한다.
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxUserRegistered, model.UserEventPayload{UserID: 7, Role: model.RoleGuest})).
+		Return(nil)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionSave, model.SessionPayload{
+			Key:      GetGuestTokenKey(7),
+			Token:    expectedToken,
+			ExpireAt: testNow.Add(defaultSessionExpire),
+		})).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t), WithClock(clock.NewFake(testNow)))
+	id, secret, token, err := service.RegisterGuest(ctx, deviceID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+	assert.NotEmpty(t, secret)
+	assert.Equal(t, expectedToken, token)
+
+	mockUserRepo.AssertExpectations(t)
+	mockJWTGenerator.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRegisterGuest_SessionExpire(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("CreateGuest", ctx, mock.Anything, mock.Anything).
+		Return(nil)
+
+	mockJWTGenerator := new(MockJWTGenerator)
+	mockJWTGenerator.
+		On("GenerateToken", ctx, mock.Anything).
+		Return("jwt-token-value", nil)
+
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxUserRegistered, model.UserEventPayload{Role: model.RoleGuest})).
+		Return(nil)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionSave, model.SessionPayload{
+			Key:      GetGuestTokenKey(0),
+			Token:    "jwt-token-value",
+			ExpireAt: testNow.Add(time.Hour),
+		})).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t),
+		WithClock(clock.NewFake(testNow)),
+		WithSessionExpire(time.Hour),
+	)
+	_, _, _, err = service.RegisterGuest(ctx, "device-1")
+	assert.NoError(t, err)
+
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUpgradeGuest_Success(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	email := "test@example.com"
+	password := "password123"
+	guest := newGuest(t, 7, "device-1", "secret")
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByID", ctx, xdb, guest.ID).
+		Return(guest, nil)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, email).
+		Return(nil, sql.ErrNoRows)
+	mockUserRepo.
+		On("UpgradeGuest", ctx, mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.ID == guest.ID && u.Email == email && u.Role == model.RoleUser &&
+				bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+		})).
+		Return(nil)
+
+	mockOutbox := new(MockOutboxRepository)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxSessionDelete, model.SessionPayload{Key: GetGuestTokenKey(guest.ID)})).
+		Return(nil)
+	mockOutbox.
+		On("Add", ctx, mock.Anything, outboxMessage(model.OutboxGuestUpgraded, model.UserEventPayload{UserID: guest.ID, Role: model.RoleUser})).
+		Return(nil)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	service := NewAuthService(xdb, mockUserRepo, mockOutbox, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+	err = service.UpgradeGuest(ctx, guest.ID, "device-1", "secret", email, password)
+	assert.NoError(t, err)
+
+	mockUserRepo.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUpgradeGuest_Concealed(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	existingEmail := "test@example.com"
+	racedEmail := "raced@example.com"
+	guest := newGuest(t, 7, "device-1", "secret")
+	ctx := context.Background()
+
+	// 이미 가입된 이메일도, 동시에 가입되어 유니크 제약 조건에 걸린 이메일도 성공과 같은 응답을 받는다.
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("GetUserByID", ctx, xdb, guest.ID).
+		Return(guest, nil)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, existingEmail).
+		Return(&model.User{ID: 1, Email: existingEmail, Role: model.RoleAdmin}, nil)
+	mockUserRepo.
+		On("GetUserByEmail", ctx, xdb, racedEmail).
+		Return(nil, sql.ErrNoRows)
+	mockUserRepo.
+		On("UpgradeGuest", ctx, mock.Anything, mock.AnythingOfType("*model.User")).
+		Return(repository.ErrDuplicateEmail)
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+
+	mockMailer := new(MockMailer)
+	mockMailer.
+		On("SendAlreadyRegistered", ctx, existingEmail).
+		Return(nil)
+	mockMailer.
+		On("SendAlreadyRegistered", ctx, racedEmail).
+		Return(nil)
+
+	service := NewAuthService(xdb, mockUserRepo, nil, nil, newHasher(t), WithClock(clock.NewFake(testNow)), WithConcealedRegistration(mockMailer))
+	assert.NoError(t, service.UpgradeGuest(ctx, guest.ID, "device-1", "secret", existingEmail, "password123"))
+	assert.NoError(t, service.UpgradeGuest(ctx, guest.ID, "device-1", "secret", racedEmail, "password123"))
+	// 핸들러는 이 값으로 성공과 이미 가입된 이메일에 같은 응답을 준다.
+	assert.True(t, service.ConcealsRegistration())
+
+	mockUserRepo.AssertExpectations(t)
+	mockUserRepo.AssertNumberOfCalls(t, "UpgradeGuest", 1)
+	mockMailer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUpgradeGuest_InvalidCredential(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	xdb := sqlx.NewDb(db, "mysql")
+
+	guest := newGuest(t, 7, "device-1", "secret")
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		deviceID string
+		secret   string
+	}{
+		"wrong device": {deviceID: "device-2", secret: "secret"},
+		"wrong secret": {deviceID: "device-1", secret: "other"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+			mockUserRepo.
+				On("GetUserByID", ctx, xdb, guest.ID).
+				Return(guest, nil)
+
+			service := NewAuthService(xdb, mockUserRepo, nil, nil, newHasher(t), WithClock(clock.NewFake(testNow)))
+			err := service.UpgradeGuest(ctx, guest.ID, tt.deviceID, tt.secret, "test@example.com", "password123")
+			assert.EqualError(t, err, "invalid guest credential")
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// replicaRouter 조회는 replica 로, 쓰기는 primary 로 보낸다.
+type replicaRouter struct {
+	primary, replica *sqlx.DB
+}
+
+func (r replicaRouter) Reader(context.Context) mysqlconn.Queryer {
+	return r.replica
+}
+
+func (r replicaRouter) Writer(context.Context) mysqlconn.Execer {
+	return r.primary
+}
+
+func TestLoginGuest_Replica(t *testing.T) {
+	newDB := func() (*sqlx.DB, sqlmock.Sqlmock) {
+		db, mockDB, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		return sqlx.NewDb(db, "mysql"), mockDB
+	}
+	primary, mockPrimary := newDB()
+	replica, _ := newDB()
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		replicaErr error
+		reads      []*sqlx.DB
+	}{
+		"read from replica": {reads: []*sqlx.DB{replica}},
+		// 복제 지연으로 방금 만든 게스트가 없으면 primary 에서 다시 찾는다.
+		"fallback to primary": {replicaErr: sql.ErrNoRows, reads: []*sqlx.DB{replica, primary}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			guest := newGuest(t, 7, "device-1", "secret")
+
+			mockUserRepo := new(MockUserRepository)
+			if tt.replicaErr != nil {
+				mockUserRepo.On("GetUserByID", ctx, replica, guest.ID).Return(nil, tt.replicaErr)
+				mockUserRepo.On("GetUserByID", ctx, primary, guest.ID).Return(guest, nil)
+			} else {
+				mockUserRepo.On("GetUserByID", ctx, replica, guest.ID).Return(guest, nil)
+			}
+			mockUserRepo.On("UpdateLastLogin", ctx, mock.Anything, guest).Return(nil)
+			mockPrimary.ExpectBegin()
+			mockPrimary.ExpectCommit()
+
+			mockJWTGenerator := new(MockJWTGenerator)
+			mockJWTGenerator.On("GenerateToken", ctx, mock.Anything).Return("jwt-token-value", nil)
+			mockOutbox := new(MockOutboxRepository)
+			mockOutbox.On("Add", ctx, mock.Anything, mock.Anything).Return(nil)
+
+			service := NewAuthService(primary, mockUserRepo, mockOutbox, mockJWTGenerator, newHasher(t),
+				WithClock(clock.NewFake(testNow)),
+				WithDBRouter(replicaRouter{primary: primary, replica: replica}),
+			)
+			token, err := service.LoginGuest(ctx, guest.ID, "device-1", "secret")
+			assert.NoError(t, err)
+			assert.Equal(t, "jwt-token-value", token)
+
+			for _, db := range tt.reads {
+				mockUserRepo.AssertCalled(t, "GetUserByID", ctx, db, guest.ID)
+			}
+			mockUserRepo.AssertNumberOfCalls(t, "GetUserByID", len(tt.reads))
+			mockUserRepo.AssertExpectations(t)
+			assert.NoError(t, mockPrimary.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGuestCleaner_Cleanup(t *testing.T) {
+	now := time.Now()
+	ttl := 24 * time.Hour
+
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.
+		On("DeleteStaleGuests", mock.Anything, mock.Anything, now.Add(-ttl)).
+		Return(int64(2), nil)
+
+	cleaner := NewGuestCleaner(nil, mockUserRepo, time.Hour, ttl)
+	n, err := cleaner.Cleanup(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	mockUserRepo.AssertExpectations(t)
+}
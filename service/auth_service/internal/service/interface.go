@@ -3,23 +3,63 @@ package service
 import (
 	"context"
 	"pkg/auth"
+	"pkg/mysqlconn"
 	"time"
 
 	"auth_service/internal/model"
 	"auth_service/internal/repository"
 )
 
+// DBRouter 조회는 복제본으로, 쓰기는 primary 로 보낸다. mysqlconn.MySQLConn 이 구현한다.
+type DBRouter interface {
+	Reader(ctx context.Context) mysqlconn.Queryer
+	Writer(ctx context.Context) mysqlconn.Execer
+}
+
 type UserRepository interface {
 	GetUserByEmail(ctx context.Context, q repository.Queryer, email string) (*model.User, error)
 	CreateUser(ctx context.Context, exec repository.Execer, user *model.User) error
 	UpdateLastLogin(ctx context.Context, exec repository.Execer, user *model.User) error
+	UpdatePassword(ctx context.Context, exec repository.Execer, user *model.User) error
+	CreateGuest(ctx context.Context, exec repository.Execer, user *model.User) error
+	GetUserByID(ctx context.Context, q repository.Queryer, id int64) (*model.User, error)
+	UpgradeGuest(ctx context.Context, exec repository.Execer, user *model.User) error
+	DeleteStaleGuests(ctx context.Context, exec repository.Execer, before time.Time) (int64, error)
+	ListUsers(ctx context.Context, q repository.Queryer, afterID int64, limit int) ([]model.User, error)
+	UpdateRole(ctx context.Context, exec repository.Execer, user *model.User) error
+	DisableUser(ctx context.Context, exec repository.Execer, user *model.User) error
 }
 
-type RedisClient interface {
-	Save(ctx context.Context, key, value string, expiration time.Duration) error
-	Delete(ctx context.Context, key string) error
+// OutboxRepository Redis 세션과 도메인 이벤트 같은 부수 효과를 DB 변경과 같은 트랜잭션에 기록한다.
+type OutboxRepository interface {
+	Add(ctx context.Context, exec repository.Execer, msg *model.OutboxMessage) error
+}
+
+// Notifier 커밋한 outbox 메시지를 relay 가 바로 처리하도록 알린다.
+type Notifier interface {
+	Notify()
 }
 
 type JWTGenerator interface {
 	GenerateToken(ctx context.Context, user auth.User) (string, error)
 }
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (bool, error)
+	NeedsRehash(hash string) bool
+}
+
+// Mailer 가입 결과를 이메일 소유자에게 알린다.
+type Mailer interface {
+	SendRegistered(ctx context.Context, email string) error
+	SendAlreadyRegistered(ctx context.Context, email string) error
+}
+
+// Metrics 가입, 로그인, 토큰 발급/폐기 횟수를 기록한다.
+type Metrics interface {
+	IncRegistrations()
+	IncLogins(outcome string)
+	IncTokensIssued()
+	IncTokensRevoked()
+}
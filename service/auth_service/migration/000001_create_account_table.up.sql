@@ -1,4 +1,4 @@
-CREATE TABLE IF NOT EXISTS `auth`.`account`
+CREATE TABLE IF NOT EXISTS `account`
 (
     `id`         BIGINT AUTO_INCREMENT PRIMARY KEY,
     `email`      VARCHAR(128) NOT NULL UNIQUE,
@@ -0,0 +1,4 @@
+DELETE FROM `account` WHERE `email` IS NULL;
+ALTER TABLE `account`
+    DROP COLUMN `device_id`,
+    MODIFY `email` VARCHAR(128) NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE `account`
+    MODIFY `email` VARCHAR(128) NULL,
+    ADD COLUMN `device_id` VARCHAR(128) NULL AFTER `role`;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `outbox`
+(
+    `id`           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    `kind`         VARCHAR(64)  NOT NULL,
+    `payload`      JSON         NOT NULL,
+    `attempts`     INT          NOT NULL DEFAULT 0,
+    `last_error`   VARCHAR(512) NULL,
+    `available_at` TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `created_at`   TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    INDEX `idx_outbox_available_at` (`available_at`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
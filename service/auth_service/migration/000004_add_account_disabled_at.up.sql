@@ -0,0 +1,2 @@
+ALTER TABLE `account`
+    ADD COLUMN `disabled_at` TIMESTAMP NULL AFTER `last_login`;
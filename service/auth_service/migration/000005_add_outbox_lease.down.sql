@@ -0,0 +1,6 @@
+ALTER TABLE `outbox`
+    DROP INDEX `idx_outbox_ordering_key`,
+    ADD INDEX `idx_outbox_available_at` (`available_at`),
+    DROP COLUMN `claim_token`,
+    DROP COLUMN `claimed_until`,
+    DROP COLUMN `ordering_key`;
@@ -0,0 +1,6 @@
+ALTER TABLE `outbox`
+    ADD COLUMN `ordering_key`  VARCHAR(255) NOT NULL DEFAULT '' AFTER `kind`,
+    ADD COLUMN `claimed_until` TIMESTAMP(3) NULL AFTER `available_at`,
+    ADD COLUMN `claim_token`   CHAR(36)     NULL AFTER `claimed_until`,
+    DROP INDEX `idx_outbox_available_at`,
+    ADD INDEX `idx_outbox_ordering_key` (`ordering_key`, `id`);
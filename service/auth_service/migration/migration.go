@@ -0,0 +1,10 @@
+// Package migration 스키마 변경 파일을 바이너리에 포함한다.
+package migration
+
+import "embed"
+
+// FS golang-migrate 형식({version}_{title}.{up|down}.sql)의 파일을 담는다.
+// 스키마 이름은 적지 않고 접속한 DB 에 적용한다.
+//
+//go:embed *.sql
+var FS embed.FS